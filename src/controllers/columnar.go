@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/decimal128"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	contentTypeParquet = "application/vnd.apache.parquet"
+	contentTypeArrow   = "application/vnd.apache.arrow.stream"
+
+	// columnarBatchSize is the number of results per Arrow record batch and
+	// therefore per Parquet row group
+	columnarBatchSize = 1024
+)
+
+// recordSink receives finished record batches
+type recordSink interface {
+	Write(rec arrow.Record) error
+	Close() error
+}
+
+// columnarWriter streams results as Apache Parquet or as an Arrow IPC stream.
+// Results are buffered into record batches of columnarBatchSize rows. Like the
+// delimited formats the outcome is reported in the X-Axis-Status trailer.
+type columnarWriter struct {
+	c           *gin.Context
+	contentType string
+	schema      *arrow.Schema
+	columns     []string
+	builder     *array.RecordBuilder
+	sink        recordSink
+	pending     int
+}
+
+func newColumnarWriter(c *gin.Context, tmpl *compiledTemplate, columnTypes []*sql.ColumnType, contentType string) *columnarWriter {
+	schema := arrowSchema(tmpl, columnTypes)
+	return &columnarWriter{
+		c:           c,
+		contentType: contentType,
+		schema:      schema,
+		columns:     tmpl.root.keys,
+		builder:     array.NewRecordBuilder(memory.DefaultAllocator, schema),
+	}
+}
+
+func (w *columnarWriter) streaming() bool { return true }
+
+func (w *columnarWriter) start() error {
+	if w.sink != nil {
+		return nil
+	}
+
+	w.c.Header("Content-Type", w.contentType)
+	w.c.Header("Trailer", "X-Axis-Status")
+	w.c.Status(http.StatusOK)
+
+	if w.contentType == contentTypeParquet {
+		fw, err := pqarrow.NewFileWriter(w.schema, w.c.Writer, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+		if err != nil {
+			return err
+		}
+		w.sink = fw
+	} else {
+		w.sink = ipc.NewWriter(w.c.Writer, ipc.WithSchema(w.schema))
+	}
+	return nil
+}
+
+func (w *columnarWriter) write(result map[string]any) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	for i, col := range w.columns {
+		if err := appendArrowValue(w.builder.Field(i), result[col]); err != nil {
+			message := fmt.Sprintf("Column %q: %v", col, err)
+			w.fail(http.StatusInternalServerError, message)
+			return errors.New(message)
+		}
+	}
+	w.pending++
+
+	if w.pending >= columnarBatchSize {
+		return w.flushBatch()
+	}
+	return nil
+}
+
+func (w *columnarWriter) flushBatch() error {
+	if w.pending == 0 {
+		return nil
+	}
+
+	rec := w.builder.NewRecord()
+	defer rec.Release()
+	w.pending = 0
+
+	if err := w.sink.Write(rec); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+func (w *columnarWriter) fail(status int, message string) {
+	defer w.builder.Release()
+	if w.sink == nil {
+		w.c.JSON(status, gin.H{"error": message})
+		return
+	}
+	// Leave the file unterminated so readers cannot mistake it for a complete result
+	w.c.Writer.Header().Set("X-Axis-Status", "error: "+message)
+}
+
+func (w *columnarWriter) finish() {
+	defer w.builder.Release()
+	if err := w.start(); err != nil {
+		w.c.Writer.Header().Set("X-Axis-Status", "error: "+err.Error())
+		return
+	}
+
+	err := w.flushBatch()
+	if err == nil {
+		err = w.sink.Close()
+	}
+	if err != nil {
+		w.c.Writer.Header().Set("X-Axis-Status", "error: "+err.Error())
+		return
+	}
+	w.c.Writer.Flush()
+	w.c.Writer.Header().Set("X-Axis-Status", "success")
+}
+
+// arrowSchema derives one column per top-level template key. Keys that map
+// straight to a query column in typed mode take their type from the database
+// column; everything else, including anonymized and nested values, is a string.
+func arrowSchema(tmpl *compiledTemplate, columnTypes []*sql.ColumnType) *arrow.Schema {
+	byName := make(map[string]*sql.ColumnType, len(columnTypes))
+	for _, ct := range columnTypes {
+		byName[ct.Name()] = ct
+	}
+
+	fields := make([]arrow.Field, len(tmpl.root.keys))
+	for i, key := range tmpl.root.keys {
+		node := tmpl.root.fields[key]
+
+		var dataType arrow.DataType = arrow.BinaryTypes.String
+		switch {
+		case node.rule != nil:
+		case node.kind == nodeColumn:
+			if ct, ok := byName[node.column]; ok {
+				dataType = arrowType(ct)
+			}
+		case node.kind == nodeLiteral:
+			switch node.literal.(type) {
+			case float64:
+				dataType = arrow.PrimitiveTypes.Float64
+			case bool:
+				dataType = arrow.FixedWidthTypes.Boolean
+			}
+		}
+		fields[i] = arrow.Field{Name: key, Type: dataType, Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowType maps a database column type onto an Arrow type
+func arrowType(ct *sql.ColumnType) arrow.DataType {
+	switch strings.ToUpper(ct.DatabaseTypeName()) {
+	case "INT", "INT2", "INT4", "INT8", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT",
+		"UNSIGNED INT", "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "YEAR":
+		return arrow.PrimitiveTypes.Int64
+	case "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL":
+		return arrow.PrimitiveTypes.Float64
+	case "DECIMAL", "NUMERIC":
+		if precision, scale, ok := ct.DecimalSize(); ok && precision > 0 && precision <= 38 {
+			return &arrow.Decimal128Type{Precision: int32(precision), Scale: int32(scale)}
+		}
+		return arrow.PrimitiveTypes.Float64
+	case "BOOL", "BOOLEAN":
+		return arrow.FixedWidthTypes.Boolean
+	case "BIT":
+		// SQL Server's BIT is a boolean its driver scans as bool. MySQL and
+		// PostgreSQL BIT(n) are bit strings, kept as strings.
+		if ct.ScanType() == reflect.TypeOf(true) {
+			return arrow.FixedWidthTypes.Boolean
+		}
+		return arrow.BinaryTypes.String
+	case "DATE":
+		return arrow.FixedWidthTypes.Date32
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET":
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+// appendArrowValue appends a result value to a column builder, writing null
+// for NULL. A value that cannot be represented in the column type is an
+// error rather than a silent null; null is still appended to keep the
+// columns aligned.
+func appendArrowValue(b array.Builder, value any) error {
+	if value == nil {
+		b.AppendNull()
+		return nil
+	}
+
+	switch b := b.(type) {
+	case *array.StringBuilder:
+		b.Append(formatCell(value))
+		return nil
+	case *array.Int64Builder:
+		if n, ok := toInt64(value); ok {
+			b.Append(n)
+			return nil
+		}
+	case *array.Float64Builder:
+		if f, ok := toFloat64(value); ok {
+			b.Append(f)
+			return nil
+		}
+	case *array.BooleanBuilder:
+		switch v := value.(type) {
+		case bool:
+			b.Append(v)
+			return nil
+		case int64:
+			b.Append(v != 0)
+			return nil
+		}
+	case *array.Decimal128Builder:
+		dt := b.Type().(*arrow.Decimal128Type)
+		if n, err := decimal128.FromString(fmt.Sprint(value), dt.Precision, dt.Scale); err == nil {
+			b.Append(n)
+			return nil
+		}
+	case *array.Date32Builder:
+		if t, ok := toTime(value); ok {
+			b.Append(arrow.Date32FromTime(t))
+			return nil
+		}
+	case *array.TimestampBuilder:
+		if t, ok := toTime(value); ok {
+			b.Append(arrow.Timestamp(t.UTC().UnixMicro()))
+			return nil
+		}
+	}
+	b.AppendNull()
+	return fmt.Errorf("cannot convert %T to %s", value, b.Type())
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet/file"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResultWriter_ColumnarNegotiation(t *testing.T) {
+	c, _ := newOutputContext("/contracts/x/execute?format=parquet", "")
+	assert.IsType(t, &columnarWriter{}, newResultWriter(c, "x", testTemplate(t), nil))
+
+	c, _ = newOutputContext("/contracts/x/execute", contentTypeArrow)
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+	assert.IsType(t, &columnarWriter{}, out)
+	assert.Equal(t, contentTypeArrow, out.(*columnarWriter).contentType)
+}
+
+func TestColumnarWriter_Arrow(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute?format=arrow", "")
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+
+	assert.NoError(t, out.write(map[string]any{
+		"name":       "Oslo",
+		"population": json.Number("508726"),
+		"address":    map[string]any{"district": "Oslo"},
+	}))
+	assert.NoError(t, out.write(map[string]any{"name": "Bergen", "population": nil, "address": nil}))
+	out.finish()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeArrow, w.Header().Get("Content-Type"))
+	assert.Equal(t, "success", w.Header().Get("X-Axis-Status"))
+
+	reader, err := ipc.NewReader(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	defer reader.Release()
+
+	assert.Equal(t, []string{"address", "name", "population"}, fieldNames(reader.Schema()))
+	assert.True(t, reader.Next())
+	rec := reader.Record()
+	assert.Equal(t, int64(2), rec.NumRows())
+
+	names := rec.Column(1).(*array.String)
+	assert.Equal(t, "Oslo", names.Value(0))
+	assert.Equal(t, "Bergen", names.Value(1))
+	assert.True(t, rec.Column(2).IsNull(1))
+}
+
+func TestColumnarWriter_Parquet(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute", contentTypeParquet)
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+
+	for i := 0; i < columnarBatchSize+1; i++ {
+		assert.NoError(t, out.write(map[string]any{"name": "Oslo", "population": int64(i)}))
+	}
+	out.finish()
+
+	assert.Equal(t, contentTypeParquet, w.Header().Get("Content-Type"))
+	assert.Equal(t, "success", w.Header().Get("X-Axis-Status"))
+
+	pf, err := file.NewParquetReader(bytes.NewReader(w.Body.Bytes()))
+	assert.NoError(t, err)
+	defer pf.Close()
+	assert.Equal(t, 2, pf.NumRowGroups())
+
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	assert.NoError(t, err)
+	table, err := fr.ReadTable(context.Background())
+	assert.NoError(t, err)
+	defer table.Release()
+	assert.Equal(t, int64(columnarBatchSize+1), table.NumRows())
+}
+
+func TestColumnarWriter_FailBeforeStart(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute?format=parquet", "")
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+	out.fail(http.StatusInternalServerError, "Error reading rows")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "Error reading rows"}`, w.Body.String())
+}
+
+func TestAppendArrowValue(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "i", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "f", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: "d", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}, Nullable: true},
+		{Name: "ts", Type: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}, Nullable: true},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+
+	when := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, appendArrowValue(b.Field(0), json.Number("42")))
+	assert.NoError(t, appendArrowValue(b.Field(1), "3.5"))
+	assert.NoError(t, appendArrowValue(b.Field(2), json.Number("12.50")))
+	assert.NoError(t, appendArrowValue(b.Field(3), when))
+
+	assert.EqualError(t, appendArrowValue(b.Field(0), "not a number"), "cannot convert string to int64")
+	assert.NoError(t, appendArrowValue(b.Field(1), nil))
+	assert.Error(t, appendArrowValue(b.Field(2), "x"))
+	assert.NoError(t, appendArrowValue(b.Field(3), "2024-05-17"))
+
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	assert.Equal(t, int64(42), rec.Column(0).(*array.Int64).Value(0))
+	assert.Equal(t, 3.5, rec.Column(1).(*array.Float64).Value(0))
+	assert.Equal(t, "12.5", rec.Column(2).(*array.Decimal128).ValueStr(0))
+	assert.Equal(t, arrow.Timestamp(when.UnixMicro()), rec.Column(3).(*array.Timestamp).Value(0))
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, rec.Column(i).IsNull(1))
+	}
+	assert.Equal(t, arrow.Timestamp(time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC).UnixMicro()), rec.Column(3).(*array.Timestamp).Value(1))
+}
+
+func TestColumnarWriter_ReportsUnconvertibleValues(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{{Name: "flag", Type: arrow.FixedWidthTypes.Boolean, Nullable: true}}, nil)
+	c, w := newOutputContext("/contracts/x/execute?format=arrow", "")
+	out := &columnarWriter{
+		c:           c,
+		contentType: contentTypeArrow,
+		schema:      schema,
+		columns:     []string{"flag"},
+		builder:     array.NewRecordBuilder(memory.DefaultAllocator, schema),
+	}
+
+	assert.NoError(t, out.write(map[string]any{"flag": true}))
+	assert.Error(t, out.write(map[string]any{"flag": "\x01"}))
+	assert.Equal(t, `error: Column "flag": cannot convert string to bool`, w.Header().Get("X-Axis-Status"))
+}
+
+func TestArrowType_Bit(t *testing.T) {
+	db, err := sql.Open("sqlite", newSQLiteDatabase(t, "CREATE TABLE t (b BIT, ok BOOLEAN)"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT b, ok FROM t")
+	assert.NoError(t, err)
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	assert.NoError(t, err)
+
+	// Only a driver that scans BIT as bool gets a boolean column
+	assert.Equal(t, arrow.BinaryTypes.String, arrowType(columnTypes[0]))
+	assert.Equal(t, arrow.FixedWidthTypes.Boolean, arrowType(columnTypes[1]))
+}
+
+func fieldNames(schema *arrow.Schema) []string {
+	names := make([]string, len(schema.Fields()))
+	for i, f := range schema.Fields() {
+		names[i] = f.Name
+	}
+	return names
+}
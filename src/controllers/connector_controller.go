@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"axis/src/models"
-	"database/sql"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +16,23 @@ func CreateConnector(c *gin.Context) {
 		return
 	}
 
+	if err := validatePoolConfig(connector.Pool); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSecretReferences(connector.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateConnectorPath(connector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !connectsIfRequested(c, connector) {
+		return
+	}
+
 	// Generate unique ID
 	connector.ID = uuid.New().String()
 
@@ -25,7 +41,7 @@ func CreateConnector(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, connector)
+	c.JSON(http.StatusCreated, newConnectorView(connector))
 }
 
 // ListConnectors returns all connectors
@@ -36,7 +52,11 @@ func ListConnectors(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, connectors)
+	views := make([]models.ConnectorView, len(connectors))
+	for i, connector := range connectors {
+		views[i] = newConnectorView(connector)
+	}
+	c.JSON(http.StatusOK, views)
 }
 
 // GetConnector returns a specific connector by ID
@@ -53,7 +73,7 @@ func GetConnector(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, connector)
+	c.JSON(http.StatusOK, newConnectorView(*connector))
 }
 
 // UpdateConnector updates an existing connector
@@ -61,7 +81,7 @@ func UpdateConnector(c *gin.Context) {
 	id := c.Param("id")
 
 	// Check if connector exists
-	_, err := loadConnector(id)
+	current, err := loadConnector(id)
 	if err != nil {
 		if err.Error() == "connector not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Connector not found"})
@@ -77,13 +97,38 @@ func UpdateConnector(c *gin.Context) {
 		return
 	}
 
+	if err := validatePoolConfig(connector.Pool); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSecretReferences(connector.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateConnectorPath(connector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Secrets are never sent to clients, so updates may omit them
+	if err := keepStoredSecrets(&connector, current); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !connectsIfRequested(c, connector) {
+		return
+	}
+
 	connector.ID = id
 	if err := saveConnector(&connector); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update connector"})
 		return
 	}
+	// Requests after the update connect with the new settings
+	pools.invalidate(id)
 
-	c.JSON(http.StatusOK, connector)
+	c.JSON(http.StatusOK, newConnectorView(connector))
 }
 
 // DeleteConnector removes a connector
@@ -98,11 +143,13 @@ func DeleteConnector(c *gin.Context) {
 		}
 		return
 	}
+	pools.invalidate(id)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Connector deleted successfully"})
 }
 
-// TestConnection tests if a connector can establish a connection
+// TestConnection tests if a connector can establish a connection and reports
+// what it found: latency, server details, or why the connection failed
 func TestConnection(c *gin.Context) {
 	id := c.Param("id")
 
@@ -117,23 +164,70 @@ func TestConnection(c *gin.Context) {
 		return
 	}
 
-	// 2. Build the connection string (assuming you have a helper function or similar logic)
-	connStr := buildConnectionString(connector.Config, connector.Type)
-
-	// 3. Open the database connection
-	db, err := sql.Open(connector.Type, connStr)
+	// 2. Connect within the connector's timeout
+	diag, err := diagnoseConnector(c.Request.Context(), connector)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open database connection"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 3. Report the outcome either way
+	c.JSON(diagnosticsStatus(diag), diag)
+}
+
+// TestConnectorDefinition tests connection settings that have not been saved
+func TestConnectorDefinition(c *gin.Context) {
+	var connector models.Connector
+	if err := c.ShouldBindJSON(&connector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer db.Close()
 
-	// 4. Ping the database
-	if err := db.Ping(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection failed"})
+	if err := validatePoolConfig(connector.Pool); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 5. Return success if everything went well
-	c.JSON(http.StatusOK, gin.H{"status": "connection successful"})
+	// References are only resolved for saved connectors, so an unsaved body
+	// cannot send a server secret to a host of the caller's choosing
+	if err := rejectSecretReferences(connector.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateConnectorPath(connector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Without an ID the test stays clear of the pools of saved connectors
+	connector.ID = ""
+	diag, err := diagnoseConnector(c.Request.Context(), &connector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(diagnosticsStatus(diag), diag)
+}
+
+// connectsIfRequested tests a connector about to be saved when the request
+// asks for ?validate=true. When it cannot connect the response is written
+// and false is returned.
+func connectsIfRequested(c *gin.Context, connector models.Connector) bool {
+	if c.Query("validate") != "true" {
+		return true
+	}
+
+	// Test the new settings, not the pool opened with the stored ones
+	connector.ID = ""
+	diag, err := diagnoseConnector(c.Request.Context(), &connector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if diag.Error != "" {
+		c.JSON(diagnosticsStatus(diag), diag)
+		return false
+	}
+	return true
 }
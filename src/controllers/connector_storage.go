@@ -2,77 +2,36 @@ package controllers
 
 import (
 	"axis/src/models"
-	"encoding/json"
-	"errors"
-	"os"
-	"path/filepath"
+	"axis/src/storage"
 )
 
 const connectorsDir = "../connectors"
 
-func init() {
-	// Ensure connectors directory exists
-	if err := os.MkdirAll(connectorsDir, 0755); err != nil {
-		panic(err)
-	}
+// connectorStore is the backend used by the connector handlers. It defaults to
+// JSON files on disk and can be replaced at startup with SetConnectorStore.
+var connectorStore storage.ConnectorStore = storage.NewFileStore(contractsDir, connectorsDir)
+
+// SetConnectorStore injects the backend used to persist connectors
+func SetConnectorStore(store storage.ConnectorStore) {
+	connectorStore = store
 }
 
 var saveConnector = func(connector *models.Connector) error {
-	data, err := json.Marshal(connector)
+	sealed, err := sealSecrets(*connector)
 	if err != nil {
 		return err
 	}
-
-	filename := filepath.Join(connectorsDir, connector.ID+".json")
-	return os.WriteFile(filename, data, 0644)
+	return connectorStore.SaveConnector(&sealed)
 }
 
 var loadConnector = func(id string) (*models.Connector, error) {
-	filename := filepath.Join(connectorsDir, id+".json")
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("connector not found")
-		}
-		return nil, err
-	}
-
-	var connector models.Connector
-	if err := json.Unmarshal(data, &connector); err != nil {
-		return nil, err
-	}
-	return &connector, nil
+	return connectorStore.LoadConnector(id)
 }
 
 func listConnectors() ([]models.Connector, error) {
-	files, err := os.ReadDir(connectorsDir)
-	if err != nil {
-		return []models.Connector{}, err
-	}
-
-	var connectors = []models.Connector{}
-	for _, file := range files {
-		if filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
-		id := file.Name()[:len(file.Name())-5] // remove .json
-		connector, err := loadConnector(id)
-		if err != nil {
-			continue
-		}
-		connectors = append(connectors, *connector)
-	}
-	return connectors, nil
+	return connectorStore.ListConnectors()
 }
 
 func deleteConnector(id string) error {
-	filename := filepath.Join(connectorsDir, id+".json")
-	if err := os.Remove(filename); err != nil {
-		if os.IsNotExist(err) {
-			return errors.New("connector not found")
-		}
-		return err
-	}
-	return nil
+	return connectorStore.DeleteConnector(id)
 }
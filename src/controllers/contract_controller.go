@@ -1,25 +1,19 @@
 package controllers
 
 import (
+	"axis/src/dialect"
 	"axis/src/models"
+	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
-	"bytes"
 	"database/sql"
-	"html/template"
-	"time"
-
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
 )
 
 // CreateContract creates a new contract
@@ -30,9 +24,27 @@ func CreateContract(c *gin.Context) {
 		return
 	}
 
+	if err := validateFieldDefinitions(contract.Query.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateParameterDefinitions(contract.Parameters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateReadOnlyQuery(contract.Query.SQLQuery); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := compileTemplate(contract.ResponseTemplate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid response template: " + err.Error()})
+		return
+	}
+
 	contract.ID = uuid.New().String()
+	contract.Version = 1
 
-	if err := saveContract(&contract); err != nil {
+	if err := publishContract(&contract); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save contract"})
 		return
 	}
@@ -73,7 +85,8 @@ func UpdateContract(c *gin.Context) {
 	id := c.Param("id")
 
 	// Check if contract exists
-	if _, err := loadContract(id); err != nil {
+	current, err := loadContract(id)
+	if err != nil {
 		if err.Error() == "contract not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Contract not found"})
 		} else {
@@ -88,15 +101,121 @@ func UpdateContract(c *gin.Context) {
 		return
 	}
 
-	contract.ID = id
-	if err := saveContract(&contract); err != nil {
+	if err := validateFieldDefinitions(contract.Query.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateParameterDefinitions(contract.Parameters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateReadOnlyQuery(contract.Query.SQLQuery); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := compileTemplate(contract.ResponseTemplate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid response template: " + err.Error()})
+		return
+	}
+
+	if err := archiveLegacyContract(current); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contract"})
 		return
 	}
 
+	contract.ID = id
+	contract.Version = current.Version + 1
+	if err := publishContract(&contract); err != nil {
+		respondPublishError(c, err, "Failed to update contract")
+		return
+	}
+
 	c.JSON(http.StatusOK, contract)
 }
 
+// ListContractVersions returns every revision of a contract, oldest first
+func ListContractVersions(c *gin.Context) {
+	id := c.Param("id")
+
+	current, err := loadContract(id)
+	if err != nil {
+		respondContractLoadError(c, err)
+		return
+	}
+
+	versions, err := listContractVersions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list contract versions"})
+		return
+	}
+
+	// Contracts created before versioning have no recorded revisions yet
+	if len(versions) == 0 {
+		if current.Version == 0 {
+			current.Version = 1
+		}
+		versions = append(versions, *current)
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// GetContractVersion returns a single revision of a contract
+func GetContractVersion(c *gin.Context) {
+	id := c.Param("id")
+
+	version, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contract version"})
+		return
+	}
+
+	contract, err := loadContractAt(id, version)
+	if err != nil {
+		respondContractLoadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contract)
+}
+
+// RollbackContract publishes an earlier revision as the newest version. The
+// history is never rewritten, so a rollback can itself be rolled back.
+func RollbackContract(c *gin.Context) {
+	id := c.Param("id")
+
+	version, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contract version"})
+		return
+	}
+
+	current, err := loadContract(id)
+	if err != nil {
+		respondContractLoadError(c, err)
+		return
+	}
+
+	if err := archiveLegacyContract(current); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back contract"})
+		return
+	}
+
+	target, err := loadContractAt(id, version)
+	if err != nil {
+		respondContractLoadError(c, err)
+		return
+	}
+
+	target.Version = current.Version + 1
+	if err := publishContract(target); err != nil {
+		respondPublishError(c, err, "Failed to roll back contract")
+		return
+	}
+
+	c.JSON(http.StatusOK, target)
+}
+
 // DeleteContract removes a contract
 func DeleteContract(c *gin.Context) {
 	id := c.Param("id")
@@ -116,6 +235,11 @@ func DeleteContract(c *gin.Context) {
 func ExecuteContract(c *gin.Context) {
 	id := c.Param("id")
 
+	if err := validateOutputFormat(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Parse request body
 	var req models.ExecuteContractRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -123,14 +247,38 @@ func ExecuteContract(c *gin.Context) {
 		return
 	}
 
-	// Load contract
-	contract, err := loadContract(id)
-	if err != nil {
-		if err.Error() == "contract not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Contract not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load contract"})
+	// Load contract, or the pinned revision when one is requested
+	var contract *models.Contract
+	var err error
+	if v := c.Query("version"); v != "" {
+		version, convErr := strconv.Atoi(v)
+		if convErr != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contract version"})
+			return
 		}
+		contract, err = loadContractAt(id, version)
+	} else {
+		contract, err = loadContract(id)
+	}
+	if err != nil {
+		respondContractLoadError(c, err)
+		return
+	}
+
+	// Check the caller's parameters against the contract definitions
+	params, paramErrors := resolveParameters(contract.Parameters, req.Parameters)
+	if len(paramErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parameters", "details": paramErrors})
+		return
+	}
+
+	// Callers may only filter and sort on fields the contract declares
+	if err := validateFilters(req.Filters, contract.Query.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSort(req.Sort, contract.Query.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -144,133 +292,177 @@ func ExecuteContract(c *gin.Context) {
 	if req.Sort != nil {
 		contract.Query.Sort = req.Sort
 	}
+	if err := validatePagination(contract.Query.Pagination); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Load the connector
-	connectorPath := filepath.Join("../connectors", fmt.Sprintf("%s.json", contract.Query.ConnectorID))
-	connectorData, err := os.ReadFile(connectorPath)
+	connector, err := loadConnector(contract.Query.ConnectorID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Connector not found"})
+		if err.Error() == "connector not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Connector not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load connector"})
+		}
 		return
 	}
 
-	var connector models.Connector
-	if err := json.Unmarshal(connectorData, &connector); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error parsing connector data"})
+	// Generate the response template
+	tmpl, err := compileTemplate(contract.ResponseTemplate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Template parsing failed"})
 		return
 	}
 
-	// Execute the SQL query
-	db, err := sql.Open(connector.Type, buildConnectionString(connector.Config, connector.Type))
+	// Run the query against the connector. It is canceled when the client
+	// disconnects, or when the timeout passes before the first row arrives.
+	ctx, firstRow, cancel := withFirstRowDeadline(c.Request.Context(), queryTimeout(connector, contract.Query))
+	defer cancel()
+
+	source, err := openRowSource(ctx, connector, contract.Query, params)
 	if err != nil {
-		fmt.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection failed"})
+		respondSourceError(c, err)
 		return
 	}
-	defer db.Close()
-
-	baseQuery := contract.Query.SQLQuery
-	whereClause, values := buildWhereClause(contract.Query.Filters, connector.Type)
-	orderByClause := buildOrderByClause(contract.Query.Sort)
+	defer source.close()
+
+	out := newResultWriter(c, id, tmpl, source.columnTypes())
+	renderer := newRowRenderer(tmpl)
+	// A stream emits each parent as soon as its key changes, so grouped
+	// streams expect the query to be ordered by the groupBy columns
+	renderer.contiguous = out.streaming()
+
+	for source.next() {
+		firstRow()
+		rowData, err := source.row()
+		if err != nil {
+			failExecution(ctx, out, http.StatusInternalServerError, "Error scanning row")
+			return
+		}
 
-	// Split the query to handle GROUP BY correctly
-	var query string
-	if strings.Contains(baseQuery, "GROUP BY") {
-		parts := strings.SplitN(baseQuery, "GROUP BY", 2)
-		query = parts[0] + whereClause + " GROUP BY" + parts[1] + orderByClause
-	} else {
-		query = baseQuery + whereClause + orderByClause
+		// parse result into template
+		rendered, err := renderer.add(rowData)
+		if err != nil {
+			out.fail(http.StatusInternalServerError, "Template execution failed")
+			return
+		}
+		for _, result := range rendered {
+			if err := out.write(result); err != nil {
+				return // The writer reported it, or the client went away
+			}
+		}
 	}
-
-	if contract.Query.Pagination != nil {
-		offset := (contract.Query.Pagination.Page - 1) * contract.Query.Pagination.PageSize
-		query += fmt.Sprintf(" LIMIT %d OFFSET %d",
-			contract.Query.Pagination.PageSize, offset)
+	if err := source.err(); err != nil {
+		failExecution(ctx, out, http.StatusInternalServerError, "Error reading rows")
+		return
 	}
 
-	rows, err := db.Query(query, values...)
+	// Emit the parents assembled from grouped rows
+	grouped, err := renderer.flush()
 	if err != nil {
-		fmt.Println(query)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Query execution failed"})
+		out.fail(http.StatusInternalServerError, "Template execution failed")
 		return
 	}
-	defer rows.Close()
-
-	// Generate the response template
-	var results []map[string]any
-	columns, _ := rows.Columns()
-
-	for rows.Next() {
-		// Create a map for this row's data
-		rowData := make(map[string]any)
-
-		// Create properly typed containers for the scan
-		scanArgs := make([]any, len(columns))
-		for i := range columns {
-			scanArgs[i] = new(any)
+	for _, result := range grouped {
+		if err := out.write(result); err != nil {
+			return
 		}
+	}
+
+	out.finish()
+}
 
-		if err := rows.Scan(scanArgs...); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning row"})
+// failExecution reports a failure while reading rows. A passed deadline is
+// reported as a timeout and nothing is written once the client has gone.
+func failExecution(ctx context.Context, out resultWriter, status int, message string) {
+	if err := contextError(ctx); err != nil {
+		se := err.(*sourceError)
+		if se.status == statusClientClosedRequest {
 			return
 		}
+		status, message = se.status, se.message
+	}
+	out.fail(status, message)
+}
 
-		// Copy the results into the row map
-		for i, col := range columns {
-			val := *(scanArgs[i].(*any))
-			// Convert []byte to string for MySQL text-based columns
-			if b, ok := val.([]byte); ok {
-				rowData[col] = string(b)
-			} else {
-				rowData[col] = val
-			}
+// scanRow reads the current row into a map keyed by column name
+func scanRow(rows *sql.Rows, columns []string, columnTypes []*sql.ColumnType) (map[string]any, error) {
+	// Create properly typed containers for the scan
+	scanArgs := make([]any, len(columns))
+	for i := range columns {
+		scanArgs[i] = new(any)
+	}
+
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, err
+	}
+
+	// Copy the results into the row map
+	rowData := make(map[string]any, len(columns))
+	for i, col := range columns {
+		databaseType := ""
+		if i < len(columnTypes) {
+			databaseType = columnTypes[i].DatabaseTypeName()
 		}
+		rowData[col] = convertColumnValue(*(scanArgs[i].(*any)), databaseType)
+	}
+	return rowData, nil
+}
 
-		results = append(results, rowData)
+// loadContractAt returns the given revision of a contract. Contracts saved
+// before versioning existed are served as version 1.
+func loadContractAt(id string, version int) (*models.Contract, error) {
+	contract, err := loadContractVersion(id, version)
+	if err == nil || err.Error() != "contract version not found" {
+		return contract, err
 	}
 
-	// parse result into template
-	parsedResults := make([]map[string]any, 0)
-	for _, result := range results {
-		templateStr := make(map[string]any)
-		for key, value := range contract.ResponseTemplate.Template {
-			if tmpl, ok := value.(string); ok {
-				// Create a new template
-				t, err := template.New("field").Parse(tmpl)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Template parsing failed"})
-					return
-				}
+	current, loadErr := loadContract(id)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if current.Version == 0 && version == 1 {
+		current.Version = 1
+		return current, nil
+	}
+	return nil, err
+}
 
-				// Execute template with result data
-				var buf bytes.Buffer
-				if err := t.Execute(&buf, result); err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Template execution failed"})
-					return
-				}
+// archiveLegacyContract records a contract created before versioning as
+// revision 1, so its definition survives the first update.
+func archiveLegacyContract(contract *models.Contract) error {
+	if contract.Version != 0 {
+		return nil
+	}
 
-				// Check if this field needs anonymization
-				fieldValue := buf.String()
-				for _, rule := range contract.ResponseTemplate.Anonymization {
-					if rule.Field == key {
-						fieldValue = anonymizeValue(fieldValue, rule)
-						break
-					}
-				}
-				templateStr[key] = fieldValue
-			}
-		}
-		parsedResults = append(parsedResults, templateStr)
+	contract.Version = 1
+	if err := saveContractVersion(contract); err != nil && err.Error() != "contract version already exists" {
+		return err
 	}
+	return nil
+}
 
-	// Return the response
-	response := gin.H{
-		"contract_id": id,
-		"status":      "success",
-		"results":     parsedResults,
-		"timestamp":   time.Now().UTC(),
+// respondPublishError answers a failed publish. A revision number already
+// taken means another writer published first, so the client can reload the
+// contract and try again.
+func respondPublishError(c *gin.Context, err error, message string) {
+	if err.Error() == "contract version already exists" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Contract was changed concurrently; reload it and try again"})
+		return
 	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
 
-	c.JSON(http.StatusOK, response)
+func respondContractLoadError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "contract not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contract not found"})
+	case "contract version not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contract version not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load contract"})
+	}
 }
 
 // Add these helper functions before ExecuteContract
@@ -307,89 +499,129 @@ func applyMaskPattern(value string, pattern string) string {
 	return string(result)
 }
 
-// Parse the database configuration and build the connection string
-func buildConnectionString(config models.DatabaseConfig, connectorType string) string {
-	switch connectorType {
-	case "postgres":
-		return fmt.Sprintf(
-			"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-			config.Host, config.Port, config.User, config.Password, config.DBName,
-		)
-	case "mysql":
-		connString := fmt.Sprintf(
-			"%s:%s@tcp(%s:%d)/%s",
-			config.User, config.Password, config.Host, config.Port, config.DBName,
-		)
-		return connString
-	default:
-		return ""
+// Parse the database configuration and build the connection string. Secret
+// references are resolved here, so the password only exists in memory while
+// connecting.
+func buildConnectionString(config models.DatabaseConfig, connectorType string) (string, error) {
+	resolved, err := resolveSecrets(config)
+	if err != nil {
+		return "", err
+	}
+	return dialectFor(connectorType).DSN(resolved), nil
+}
+
+// dialectFor returns the dialect of a connector type, falling back to ANSI
+// syntax for types without one
+func dialectFor(dbType string) dialect.Dialect {
+	if d, ok := dialect.Lookup(dbType); ok {
+		return d
+	}
+	return dialect.ANSI{}
+}
+
+// composeQuery applies filters, sorting and pagination on top of the contract
+// SQL. The dialect wraps the base query as a derived table so the extra
+// clauses act on its output columns and compose with any SELECT, whatever
+// WHERE, GROUP BY, HAVING, ORDER BY, LIMIT, CTEs or subqueries it already
+// contains.
+func composeQuery(baseQuery, whereClause, orderByClause string, pagination *models.PaginationOptions, dbType string) string {
+	baseQuery = trimStatementEnd(baseQuery, dbType)
+	if whereClause == "" && orderByClause == "" && pagination == nil {
+		return baseQuery
+	}
+
+	d := dialectFor(dbType)
+	query := d.WrapQuery(baseQuery) + whereClause + orderByClause
+	if pagination != nil {
+		offset := (pagination.Page - 1) * pagination.PageSize
+		if offset < 0 {
+			offset = 0
+		}
+		query += d.Paginate(orderByClause, pagination.PageSize, offset)
 	}
+	return query
 }
 
-func buildWhereClause(filters []models.FilterCondition, dbType string) (string, []any) {
+// buildWhereClause turns filters into a WHERE clause. Bind parameters are
+// numbered from firstParam so they can follow the contract's own parameters.
+func buildWhereClause(filters []models.FilterCondition, dbType string, firstParam int) (string, []any) {
 	if len(filters) == 0 {
 		return "", nil
 	}
 
 	var conditions []string
 	var values []any
-	paramCount := 1
-
-	// Get the correct placeholder based on database type
-	placeholder := func(i int) string {
-		if dbType == "postgres" {
-			return fmt.Sprintf("$%d", i)
-		}
-		return "?"
-	}
+	paramCount := firstParam
+	d := dialectFor(dbType)
 
 	for _, filter := range filters {
+		column := quoteIdentifier(filter.Field, dbType)
 		switch filter.Operator {
 		case models.OperatorEquals:
-			conditions = append(conditions, fmt.Sprintf("%s = %s", filter.Field, placeholder(paramCount)))
+			if b, ok := filter.Value.(bool); ok {
+				conditions = append(conditions, fmt.Sprintf("%s = %s", column, d.BooleanLiteral(b)))
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf("%s = %s", column, placeholder(paramCount, dbType)))
 			values = append(values, filter.Value)
 			paramCount++
 		case models.OperatorNotEquals:
-			conditions = append(conditions, fmt.Sprintf("%s != %s", filter.Field, placeholder(paramCount)))
+			if b, ok := filter.Value.(bool); ok {
+				conditions = append(conditions, fmt.Sprintf("%s != %s", column, d.BooleanLiteral(b)))
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf("%s != %s", column, placeholder(paramCount, dbType)))
 			values = append(values, filter.Value)
 			paramCount++
 		case models.OperatorGreater:
-			conditions = append(conditions, fmt.Sprintf("%s > %s", filter.Field, placeholder(paramCount)))
+			conditions = append(conditions, fmt.Sprintf("%s > %s", column, placeholder(paramCount, dbType)))
 			values = append(values, filter.Value)
 			paramCount++
 		case models.OperatorLess:
-			conditions = append(conditions, fmt.Sprintf("%s < %s", filter.Field, placeholder(paramCount)))
+			conditions = append(conditions, fmt.Sprintf("%s < %s", column, placeholder(paramCount, dbType)))
 			values = append(values, filter.Value)
 			paramCount++
 		case models.OperatorLike:
-			conditions = append(conditions, fmt.Sprintf("%s LIKE %s", filter.Field, placeholder(paramCount)))
+			conditions = append(conditions, fmt.Sprintf("%s LIKE %s", column, placeholder(paramCount, dbType)))
+			values = append(values, filter.Value)
+			paramCount++
+		case models.OperatorILike:
+			conditions = append(conditions, d.CaseInsensitiveLike(column, placeholder(paramCount, dbType)))
 			values = append(values, filter.Value)
 			paramCount++
 		case models.OperatorIn:
 			if inValues, ok := filter.Value.([]any); ok {
 				placeholders := make([]string, len(inValues))
 				for i := range inValues {
-					placeholders[i] = placeholder(paramCount + i)
+					placeholders[i] = placeholder(paramCount+i, dbType)
 					values = append(values, inValues[i])
 				}
 				conditions = append(conditions, fmt.Sprintf("%s IN (%s)",
-					filter.Field, strings.Join(placeholders, ",")))
+					column, strings.Join(placeholders, ",")))
 				paramCount += len(inValues)
 			}
 		}
 	}
 
+	if len(conditions) == 0 {
+		return "", nil
+	}
 	return " WHERE " + strings.Join(conditions, " AND "), values
 }
 
-func buildOrderByClause(sortOptions []models.SortOption) string {
+func buildOrderByClause(sortOptions []models.SortOption, dbType string) string {
 	if len(sortOptions) == 0 {
 		return ""
 	}
 
 	var orderByClauses []string
 	for _, sortOption := range sortOptions {
-		orderByClauses = append(orderByClauses, fmt.Sprintf("%s %s", sortOption.Field, sortOption.Direction))
+		// Never interpolate the caller's direction, only one of the two keywords
+		direction := "asc"
+		if strings.EqualFold(sortOption.Direction, "desc") {
+			direction = "desc"
+		}
+		orderByClauses = append(orderByClauses, fmt.Sprintf("%s %s", quoteIdentifier(sortOption.Field, dbType), direction))
 	}
 
 	return " ORDER BY " + strings.Join(orderByClauses, ", ")
@@ -2,77 +2,55 @@ package controllers
 
 import (
 	"axis/src/models"
-	"encoding/json"
-	"errors"
-	"os"
-	"path/filepath"
+	"axis/src/storage"
+	"time"
 )
 
 const contractsDir = "../data-contracts"
 
-func init() {
-	// Ensure contracts directory exists
-	if err := os.MkdirAll(contractsDir, 0755); err != nil {
-		panic(err)
-	}
+// contractStore is the backend used by the contract handlers. It defaults to
+// JSON files on disk and can be replaced at startup with SetContractStore.
+var contractStore storage.ContractStore = storage.NewFileStore(contractsDir, connectorsDir)
+
+// SetContractStore injects the backend used to persist contracts
+func SetContractStore(store storage.ContractStore) {
+	contractStore = store
 }
 
 var saveContract = func(contract *models.Contract) error {
-	data, err := json.Marshal(contract)
-	if err != nil {
-		return err
-	}
-
-	filename := filepath.Join(contractsDir, contract.ID+".json")
-	return os.WriteFile(filename, data, 0644)
+	return contractStore.SaveContract(contract)
 }
 
 func loadContract(id string) (*models.Contract, error) {
-	filename := filepath.Join(contractsDir, id+".json")
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("contract not found")
-		}
-		return nil, err
-	}
-
-	var contract models.Contract
-	if err := json.Unmarshal(data, &contract); err != nil {
-		return nil, err
-	}
-	return &contract, nil
+	return contractStore.LoadContract(id)
 }
 
 func listContracts() ([]models.Contract, error) {
-	files, err := os.ReadDir(contractsDir)
-	if err != nil {
-		return []models.Contract{}, err
-	}
+	return contractStore.ListContracts()
+}
 
-	var contracts = []models.Contract{}
-	for _, file := range files {
-		if filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
+func deleteContract(id string) error {
+	return contractStore.DeleteContract(id)
+}
 
-		id := file.Name()[:len(file.Name())-5] // remove .json
-		contract, err := loadContract(id)
-		if err != nil {
-			continue
-		}
-		contracts = append(contracts, *contract)
-	}
-	return contracts, nil
+func saveContractVersion(contract *models.Contract) error {
+	return contractStore.SaveContractVersion(contract)
 }
 
-func deleteContract(id string) error {
-	filename := filepath.Join(contractsDir, id+".json")
-	if err := os.Remove(filename); err != nil {
-		if os.IsNotExist(err) {
-			return errors.New("contract not found")
-		}
+func loadContractVersion(id string, version int) (*models.Contract, error) {
+	return contractStore.LoadContractVersion(id, version)
+}
+
+func listContractVersions(id string) ([]models.Contract, error) {
+	return contractStore.ListContractVersions(id)
+}
+
+// publishContract records the contract as a new immutable revision and then
+// makes it the current definition.
+func publishContract(contract *models.Contract) error {
+	contract.UpdatedAt = time.Now().UTC()
+	if err := saveContractVersion(contract); err != nil {
 		return err
 	}
-	return nil
+	return saveContract(contract)
 }
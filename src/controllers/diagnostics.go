@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"axis/src/dialect"
+	"axis/src/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const connectionSuccessful = "connection successful"
+
+// diagnoseConnector connects within the connector's timeout and reports what
+// it found. Only an unsupported connector type is an error.
+func diagnoseConnector(ctx context.Context, connector *models.Connector) (models.ConnectionDiagnostics, error) {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout(connector))
+	defer cancel()
+
+	switch connector.Type {
+	case fileConnectorType:
+		// File connectors have no server; check that the directory can be read
+		return diagnoseFileConnector(connector), nil
+	case restConnectorType:
+		return diagnoseRESTConnector(ctx, connector), nil
+	default:
+		d, ok := dialect.Lookup(connector.Type)
+		if !ok {
+			return models.ConnectionDiagnostics{}, fmt.Errorf("Unsupported connector type %q", connector.Type)
+		}
+		return diagnoseSQLConnector(ctx, connector, d), nil
+	}
+}
+
+// diagnoseSQLConnector pings the database and reads what the server reports
+// about the session. Saved connectors use their pool; unsaved ones have no
+// ID to key a pool by and get a connection of their own.
+func diagnoseSQLConnector(ctx context.Context, connector *models.Connector, d dialect.Dialect) models.ConnectionDiagnostics {
+	var db *sql.DB
+	var err error
+	if connector.ID == "" {
+		db, err = openUnpooled(connector, d)
+		if err == nil {
+			defer db.Close()
+		}
+	} else {
+		db, err = pools.get(connector, d)
+	}
+	if err != nil {
+		return connectionFailed(ctx, connector, d, "Database connection", err, 0)
+	}
+
+	started := time.Now()
+	if err := db.PingContext(ctx); err != nil {
+		return connectionFailed(ctx, connector, d, "Database connection", err, time.Since(started))
+	}
+	diag := models.ConnectionDiagnostics{Status: connectionSuccessful, LatencyMs: milliseconds(time.Since(started))}
+
+	// The connection works even when the server keeps these to itself
+	if query := d.ServerInfo(); query != "" {
+		var version, database, user sql.NullString
+		var tls sql.NullInt64
+		if err := db.QueryRowContext(ctx, query).Scan(&version, &database, &user, &tls); err != nil {
+			return diag
+		}
+		diag.ServerVersion, diag.Database, diag.User = version.String, database.String, user.String
+		if tls.Valid {
+			usesTLS := tls.Int64 == 1
+			diag.TLS = &usesTLS
+		}
+	}
+	return diag
+}
+
+// openUnpooled opens a single connection to a connector's database outside
+// the shared pools
+func openUnpooled(connector *models.Connector, d dialect.Dialect) (*sql.DB, error) {
+	dsn, err := buildConnectionString(connector.Config, connector.Type)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(d.Driver(), dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// diagnoseRESTConnector calls the upstream's base URL
+func diagnoseRESTConnector(ctx context.Context, connector *models.Connector) models.ConnectionDiagnostics {
+	started := time.Now()
+	if err := pingRESTConnector(ctx, connector.Config); err != nil {
+		diag := connectionFailed(ctx, connector, nil, "Upstream connection", err, time.Since(started))
+		var statusErr *upstreamStatusError
+		if errors.As(err, &statusErr) && (statusErr.status == http.StatusUnauthorized || statusErr.status == http.StatusForbidden) {
+			diag.Failure = dialect.FailureAuth
+		}
+		return diag
+	}
+	diag := models.ConnectionDiagnostics{Status: connectionSuccessful, LatencyMs: milliseconds(time.Since(started))}
+	if u := connector.Config.BaseURL; strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "http://") {
+		usesTLS := strings.HasPrefix(u, "https://")
+		diag.TLS = &usesTLS
+	}
+	return diag
+}
+
+// diagnoseFileConnector checks that the directory can be read
+func diagnoseFileConnector(connector *models.Connector) models.ConnectionDiagnostics {
+	if err := pingFileDirectory(connector.Config.Path); err != nil {
+		failure := dialect.FailureUnknown
+		if os.IsNotExist(err) {
+			failure = dialect.FailureDatabaseMissing
+		}
+		return models.ConnectionDiagnostics{Error: "Connector directory is not readable", Failure: failure, Message: err.Error()}
+	}
+	return models.ConnectionDiagnostics{Status: connectionSuccessful}
+}
+
+// connectionFailed classifies a failed connection attempt. A passed deadline
+// is reported as a timeout whatever error the driver returned for it.
+func connectionFailed(ctx context.Context, connector *models.Connector, d dialect.Dialect, subject string, err error, latency time.Duration) models.ConnectionDiagnostics {
+	diag := models.ConnectionDiagnostics{
+		Error:     subject + " failed",
+		Failure:   dialect.ClassifyFailure(d, err),
+		Message:   sanitizeDriverMessage(err, connector.Config),
+		LatencyMs: milliseconds(latency),
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		diag.Failure = dialect.FailureTimeout
+	}
+	if diag.Failure == dialect.FailureTimeout {
+		diag.Error = subject + " timed out"
+	}
+	return diag
+}
+
+// diagnosticsStatus is the HTTP status answering a connection test
+func diagnosticsStatus(diag models.ConnectionDiagnostics) int {
+	switch {
+	case diag.Error == "":
+		return http.StatusOK
+	case diag.Failure == dialect.FailureTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// sanitizeDriverMessage removes the connector's credentials from an error,
+// as some drivers repeat the connection string they failed with
+func sanitizeDriverMessage(err error, config models.DatabaseConfig) string {
+	message := err.Error()
+	remove := func(secret string) (string, error) {
+		if secret != "" {
+			message = strings.ReplaceAll(message, secret, redactedSecret)
+		}
+		return secret, nil
+	}
+	mapSecrets(config, remove)
+	if resolved, err := resolveSecrets(config); err == nil {
+		mapSecrets(resolved, remove)
+	}
+	return message
+}
+
+// logConnectorError logs why an operation on a connector failed, with the
+// connector's credentials removed from the driver's message
+func logConnectorError(connector *models.Connector, operation string, err error) {
+	log.Printf("connector %s: %s: %s", connector.ID, operation, sanitizeDriverMessage(err, connector.Config))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
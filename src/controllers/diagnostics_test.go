@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"axis/src/dialect"
+	"axis/src/models"
+	"axis/src/storage"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConnection(t *testing.T, connector *models.Connector) (int, models.ConnectionDiagnostics) {
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+	assert.NoError(t, store.SaveConnector(connector))
+	defer pools.invalidate(connector.ID)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/connectors/:id/test", TestConnection)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/connectors/"+connector.ID+"/test", nil))
+
+	var diag models.ConnectionDiagnostics
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diag))
+	return rr.Code, diag
+}
+
+func TestTestConnection_ReportsServerDetails(t *testing.T) {
+	code, diag := testConnection(t, &models.Connector{
+		ID: "world", Type: "sqlite", Config: models.DatabaseConfig{Path: newSQLiteDatabase(t, "CREATE TABLE t (x INTEGER)")},
+	})
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "connection successful", diag.Status)
+	assert.Regexp(t, `^SQLite 3\.`, diag.ServerVersion)
+	assert.Equal(t, "main", diag.Database)
+	assert.Greater(t, diag.LatencyMs, 0.0)
+	assert.Nil(t, diag.TLS)
+}
+
+func TestTestConnection_ClassifiesFailures(t *testing.T) {
+	code, diag := testConnection(t, &models.Connector{
+		ID: "missing", Type: "sqlite", Config: models.DatabaseConfig{Path: filepath.Join(t.TempDir(), "missing.db")},
+	})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "Database connection failed", diag.Error)
+	assert.Equal(t, dialect.FailureDatabaseMissing, diag.Failure)
+	assert.NotEmpty(t, diag.Message)
+
+	// A port nobody listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	code, diag = testConnection(t, &models.Connector{
+		ID: "refused", Type: "postgres",
+		Config: models.DatabaseConfig{Host: "127.0.0.1", Port: port, User: "axis", Password: "hunter2", DBName: "world"},
+	})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, dialect.FailureRefused, diag.Failure)
+	assert.NotContains(t, diag.Message, "hunter2")
+
+	// An upstream that accepts the request but never answers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	code, diag = testConnection(t, &models.Connector{
+		ID: "silent", Type: restConnectorType, TimeoutSec: 1, Config: models.DatabaseConfig{BaseURL: server.URL},
+	})
+	assert.Equal(t, http.StatusGatewayTimeout, code)
+	assert.Equal(t, "Upstream connection timed out", diag.Error)
+	assert.Equal(t, dialect.FailureTimeout, diag.Failure)
+}
+
+func TestTestConnection_RESTAuthFailure(t *testing.T) {
+	server, _ := newCityService(t)
+	connector := newRESTConnector(server.URL)
+	connector.Config.Auth.Token = "wrong"
+
+	code, diag := testConnection(t, connector)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, "Upstream connection failed", diag.Error)
+	assert.Equal(t, dialect.FailureAuth, diag.Failure)
+
+	connector.Config.Auth.Token = "s3cret"
+	connector.Config.BaseURL = server.URL + "/v1/countries/NOR/cities"
+	code, diag = testConnection(t, connector)
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, *diag.TLS)
+}
+
+func TestSanitizeDriverMessage(t *testing.T) {
+	allowTestSecrets(t)
+	t.Setenv("AXIS_TEST_PG_PASSWORD", "from-env")
+	err := errors.New(`cannot parse "postgres://axis:hunter2@db/world" or "from-env"`)
+
+	message := sanitizeDriverMessage(err, models.DatabaseConfig{Password: "hunter2"})
+	assert.Equal(t, `cannot parse "postgres://axis:********@db/world" or "from-env"`, message)
+
+	message = sanitizeDriverMessage(err, models.DatabaseConfig{Password: "env:AXIS_TEST_PG_PASSWORD"})
+	assert.Equal(t, `cannot parse "postgres://axis:hunter2@db/world" or "********"`, message)
+}
+
+func TestTestConnectorDefinition(t *testing.T) {
+	allowTestDataDir(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/connectors/test", TestConnectorDefinition)
+
+	post := func(body string) (int, models.ConnectionDiagnostics) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/connectors/test", strings.NewReader(body)))
+		var diag models.ConnectionDiagnostics
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diag))
+		return rr.Code, diag
+	}
+
+	path := newSQLiteDatabase(t, "CREATE TABLE t (x INTEGER)")
+	code, diag := post(`{"id": "world", "type": "sqlite", "config": {"path": "` + path + `"}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "connection successful", diag.Status)
+	// The definition connected on its own, not through a pool
+	_, pooled := pools.pools["world"]
+	assert.False(t, pooled)
+
+	code, diag = post(`{"type": "sqlite", "config": {"path": "` + filepath.Join(t.TempDir(), "missing.db") + `"}}`)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, dialect.FailureDatabaseMissing, diag.Failure)
+
+	code, diag = post(`{"type": "oracle"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, `Unsupported connector type "oracle"`, diag.Error)
+
+	code, _ = post(`{"type": "sqlite", "pool": {"maxOpenConns": -1}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestTestConnectorDefinition_RefusesSecretReferences(t *testing.T) {
+	dir := allowTestSecrets(t)
+	t.Setenv("AXIS_TEST_API_TOKEN", "s3cret")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret"), 0o600))
+
+	// Even references the policy allows must not leave the server
+	received := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("Authorization") + r.Header.Get("X-Api-Key")
+	}))
+	defer server.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/connectors/test", TestConnectorDefinition)
+
+	for _, config := range []string{
+		`{"baseUrl": "` + server.URL + `", "auth": {"type": "bearer", "token": "env:AXIS_TEST_API_TOKEN"}}`,
+		`{"baseUrl": "` + server.URL + `", "auth": {"type": "basic", "username": "axis", "password": "file:token"}}`,
+		`{"baseUrl": "` + server.URL + `", "headers": {"X-Api-Key": "env:AXIS_TEST_API_TOKEN"}}`,
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/connectors/test", strings.NewReader(`{"type": "rest", "config": `+config+`}`)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, config)
+		assert.JSONEq(t, `{"error": "secret references are only resolved for saved connectors"}`, rr.Body.String())
+	}
+	assert.Empty(t, received)
+}
+
+func TestSaveConnector_ValidateRefusesUnreachable(t *testing.T) {
+	allowTestDataDir(t)
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/connectors", CreateConnector)
+	router.PUT("/connectors/:id", UpdateConnector)
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return rr
+	}
+
+	missing := `{"type": "sqlite", "config": {"path": "` + filepath.Join(t.TempDir(), "missing.db") + `"}}`
+	rr := send(http.MethodPost, "/connectors?validate=true", missing)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), dialect.FailureDatabaseMissing)
+	connectors, err := store.ListConnectors()
+	assert.NoError(t, err)
+	assert.Empty(t, connectors)
+
+	// Without validation the settings are stored as before
+	rr = send(http.MethodPost, "/connectors", missing)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created models.Connector
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+
+	path := newSQLiteDatabase(t, "CREATE TABLE t (x INTEGER)")
+	rr = send(http.MethodPut, "/connectors/"+created.ID+"?validate=true", `{"type": "sqlite", "config": {"path": "`+path+`"}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = send(http.MethodPut, "/connectors/"+created.ID+"?validate=true", missing)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	stored, err := store.LoadConnector(created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, path, stored.Config.Path)
+}
+
+func TestLogConnectorError_RemovesCredentials(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	connector := &models.Connector{ID: "warehouse", Config: models.DatabaseConfig{Password: "hunter2"}}
+	logConnectorError(connector, "connecting", errors.New(`dial "postgres://axis:hunter2@db/world" failed`))
+
+	assert.Contains(t, buf.String(), `connector warehouse: connecting: dial "postgres://axis:********@db/world" failed`)
+	assert.NotContains(t, buf.String(), "hunter2")
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// validateFieldDefinitions checks the field allowlist declared by a contract
+func validateFieldDefinitions(fields []models.FieldDefinition) error {
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		if field.Name == "" {
+			return fmt.Errorf("field name is required")
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("field %q is declared more than once", field.Name)
+		}
+		seen[field.Name] = true
+
+		if !isKnownType(field.Type) {
+			return fmt.Errorf("field %q has unknown type %q", field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+// validateFilters rejects filters on fields the contract does not declare as
+// filterable, unknown operators and values that do not match the field type.
+func validateFilters(filters []models.FilterCondition, fields []models.FieldDefinition) error {
+	for _, filter := range filters {
+		field, ok := findField(fields, filter.Field)
+		if !ok || !field.Filterable {
+			return fmt.Errorf("field %q is not filterable", filter.Field)
+		}
+
+		switch filter.Operator {
+		case models.OperatorEquals, models.OperatorNotEquals:
+			if err := checkFieldValue(field, filter.Value); err != nil {
+				return err
+			}
+		case models.OperatorGreater, models.OperatorLess:
+			if field.Type == models.FieldTypeBoolean {
+				return fmt.Errorf("operator %q is not supported for boolean field %q", filter.Operator, field.Name)
+			}
+			if err := checkFieldValue(field, filter.Value); err != nil {
+				return err
+			}
+		case models.OperatorLike, models.OperatorILike:
+			if field.Type != models.FieldTypeString {
+				return fmt.Errorf("operator %q is only supported for string fields", filter.Operator)
+			}
+			if err := checkFieldValue(field, filter.Value); err != nil {
+				return err
+			}
+		case models.OperatorIn:
+			values, ok := filter.Value.([]any)
+			if !ok || len(values) == 0 {
+				return fmt.Errorf("operator %q on field %q requires a non-empty array", filter.Operator, field.Name)
+			}
+			for _, value := range values {
+				if err := checkFieldValue(field, value); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unknown filter operator %q", filter.Operator)
+		}
+	}
+	return nil
+}
+
+// validateSort rejects sorting on undeclared fields and any direction other than asc or desc
+func validateSort(sortOptions []models.SortOption, fields []models.FieldDefinition) error {
+	for _, sortOption := range sortOptions {
+		field, ok := findField(fields, sortOption.Field)
+		if !ok || !field.Sortable {
+			return fmt.Errorf("field %q is not sortable", sortOption.Field)
+		}
+
+		switch strings.ToLower(sortOption.Direction) {
+		case "", "asc", "desc":
+		default:
+			return fmt.Errorf("sort direction must be \"asc\" or \"desc\", got %q", sortOption.Direction)
+		}
+	}
+	return nil
+}
+
+// validatePagination rejects pages before the first and empty page sizes,
+// which SQL, file and rest sources would each treat differently
+func validatePagination(pagination *models.PaginationOptions) error {
+	if pagination != nil && (pagination.Page < 1 || pagination.PageSize < 1) {
+		return fmt.Errorf("pagination page and pageSize must be at least 1")
+	}
+	return nil
+}
+
+func findField(fields []models.FieldDefinition, name string) (models.FieldDefinition, bool) {
+	for _, field := range fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return models.FieldDefinition{}, false
+}
+
+// checkFieldValue verifies a decoded JSON value against the declared field type
+func checkFieldValue(field models.FieldDefinition, value any) error {
+	if !matchesType(field.Type, value) {
+		return fmt.Errorf("value %v is not a valid %s for field %q", value, field.Type, field.Name)
+	}
+	return nil
+}
+
+// matchesType reports whether a decoded JSON value is valid for the given type
+func matchesType(fieldType models.FieldType, value any) bool {
+	switch fieldType {
+	case models.FieldTypeString:
+		_, ok := value.(string)
+		return ok
+	case models.FieldTypeInteger:
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case models.FieldTypeNumber:
+		_, ok := value.(float64)
+		return ok
+	case models.FieldTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case models.FieldTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if _, err := time.Parse(time.RFC3339, s); err == nil {
+			return true
+		}
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// isKnownType reports whether the type is one contracts may declare
+func isKnownType(fieldType models.FieldType) bool {
+	switch fieldType {
+	case models.FieldTypeString, models.FieldTypeInteger, models.FieldTypeNumber,
+		models.FieldTypeBoolean, models.FieldTypeDate:
+		return true
+	}
+	return false
+}
+
+// quoteIdentifier quotes a column name using the syntax of the target database
+func quoteIdentifier(name string, dbType string) string {
+	return dialectFor(dbType).QuoteIdentifier(name)
+}
+
+// placeholder returns the bind parameter syntax for the i-th argument (1-based)
+func placeholder(i int, dbType string) string {
+	return dialectFor(dbType).Placeholder(i)
+}
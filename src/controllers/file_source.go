@@ -0,0 +1,427 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileConnectorType exposes a directory of CSV and JSON Lines files as tables
+const fileConnectorType = "file"
+
+// fileExtensions are the formats a table may be stored in, in lookup order
+var fileExtensions = []string{".csv", ".jsonl", ".ndjson"}
+
+// connectorDataDir is the directory file and sqlite connectors may read
+// from. Their path can name anything the server can open, so without a
+// directory no such connector can be saved or tested.
+var connectorDataDir string
+
+// SetConnectorDataDir injects the directory file and sqlite connectors are
+// confined to
+func SetConnectorDataDir(dir string) {
+	connectorDataDir = dir
+}
+
+// validateConnectorPath rejects file and sqlite paths outside the data
+// directory. Relative paths are taken from the working directory, as they
+// are when the connector is opened.
+func validateConnectorPath(connector models.Connector) error {
+	if connector.Type != fileConnectorType && connector.Type != "sqlite" {
+		return nil
+	}
+
+	path := connector.Config.Path
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return fmt.Errorf("connector path %s is not allowed", path)
+		}
+	}
+	if connectorDataDir == "" || path == "" {
+		return fmt.Errorf("connector path %s is not allowed", path)
+	}
+
+	dir, err := filepath.Abs(connectorDataDir)
+	if err != nil {
+		return fmt.Errorf("connector path %s is not allowed", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("connector path %s is not allowed", path)
+	}
+	if rel, err := filepath.Rel(dir, abs); err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("connector path %s is not allowed", path)
+	}
+	return nil
+}
+
+// openFileSource serves one file of a file connector. Rows are filtered while
+// the file is read, then sorted and paginated in memory.
+func openFileSource(ctx context.Context, connector *models.Connector, query models.DatabaseQuery) (rowSource, error) {
+	path, err := resolveTablePath(connector.Config.Path, query.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := compileFilters(query.Filters)
+	if err != nil {
+		return nil, &sourceError{http.StatusBadRequest, err.Error()}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &sourceError{http.StatusInternalServerError, "Failed to read table"}
+	}
+	defer f.Close()
+
+	var rows []map[string]any
+	keep := func(row map[string]any) error {
+		if match(row) {
+			rows = append(rows, row)
+		}
+		return ctx.Err()
+	}
+
+	if filepath.Ext(path) == ".csv" {
+		err = readCSV(f, query.Fields, keep)
+	} else {
+		err = readJSONLines(f, keep)
+	}
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, &sourceError{http.StatusInternalServerError, "Failed to read table"}
+	}
+
+	sortRows(rows, query.Sort)
+	return newMemorySource(paginateRows(rows, query.Pagination)), nil
+}
+
+// resolveTablePath finds the file for a table name in the connector directory.
+// Names are plain file names without extension, so a contract cannot reach
+// outside the directory.
+func resolveTablePath(dir, table string) (string, error) {
+	if table == "" || table != filepath.Base(table) || strings.HasPrefix(table, ".") {
+		return "", &sourceError{http.StatusBadRequest, fmt.Sprintf("Invalid table name %q", table)}
+	}
+
+	for _, ext := range fileExtensions {
+		path := filepath.Join(dir, table+ext)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+	return "", &sourceError{http.StatusNotFound, "Table not found"}
+}
+
+// pingFileDirectory checks that a file connector points at a readable directory
+func pingFileDirectory(dir string) error {
+	_, err := os.ReadDir(dir)
+	return err
+}
+
+// readCSV reads a CSV file with a header row. Cells of declared fields are
+// converted to the field type and empty cells become NULL; all other cells
+// stay strings. Reading stops at the first error emit returns.
+func readCSV(r io.Reader, fields []models.FieldDefinition, emit func(map[string]any) error) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	columns := append([]string(nil), header...)
+	// Drop a UTF-8 byte order mark left by spreadsheet exports
+	if len(columns) > 0 {
+		columns[0] = strings.TrimPrefix(columns[0], "\ufeff")
+	}
+
+	types := make(map[string]models.FieldType, len(fields))
+	for _, field := range fields {
+		types[field.Name] = field.Type
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			row[col] = convertCell(record[i], types[col])
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+}
+
+func convertCell(cell string, fieldType models.FieldType) any {
+	if cell == "" {
+		return nil
+	}
+
+	switch fieldType {
+	case models.FieldTypeInteger:
+		if n, err := strconv.ParseInt(cell, 10, 64); err == nil {
+			return n
+		}
+	case models.FieldTypeNumber:
+		if f, err := strconv.ParseFloat(cell, 64); err == nil {
+			return f
+		}
+	case models.FieldTypeBoolean:
+		if b, err := strconv.ParseBool(cell); err == nil {
+			return b
+		}
+	}
+	return cell
+}
+
+// readJSONLines reads one JSON object per line, skipping blank lines.
+// Numbers are kept as json.Number so no precision is lost.
+func readJSONLines(r io.Reader, emit func(map[string]any) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var row map[string]any
+		if err := decoder.Decode(&row); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// compileFilters turns filter conditions into a predicate over rows. As in
+// SQL, a NULL or missing value matches no condition.
+func compileFilters(filters []models.FilterCondition) (func(map[string]any) bool, error) {
+	conditions := make([]func(map[string]any) bool, 0, len(filters))
+
+	for _, filter := range filters {
+		field, operator, want := filter.Field, filter.Operator, filter.Value
+
+		var cond func(value any) bool
+		switch operator {
+		case models.OperatorEquals:
+			cond = func(value any) bool { n, ok := compareValues(value, want); return ok && n == 0 }
+		case models.OperatorNotEquals:
+			cond = func(value any) bool { n, ok := compareValues(value, want); return ok && n != 0 }
+		case models.OperatorGreater:
+			cond = func(value any) bool { n, ok := compareValues(value, want); return ok && n > 0 }
+		case models.OperatorLess:
+			cond = func(value any) bool { n, ok := compareValues(value, want); return ok && n < 0 }
+		case models.OperatorLike, models.OperatorILike:
+			pattern, ok := want.(string)
+			if !ok {
+				return nil, fmt.Errorf("operator %q on field %q requires a string", operator, field)
+			}
+			re, err := likePattern(pattern, operator == models.OperatorILike)
+			if err != nil {
+				return nil, err
+			}
+			cond = func(value any) bool { return value != nil && re.MatchString(fmt.Sprint(value)) }
+		case models.OperatorIn:
+			options, _ := want.([]any)
+			cond = func(value any) bool {
+				for _, option := range options {
+					if n, ok := compareValues(value, option); ok && n == 0 {
+						return true
+					}
+				}
+				return false
+			}
+		default:
+			continue
+		}
+
+		conditions = append(conditions, func(row map[string]any) bool { return cond(row[field]) })
+	}
+
+	return func(row map[string]any) bool {
+		for _, cond := range conditions {
+			if !cond(row) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// likePattern translates a SQL LIKE pattern into an anchored regular
+// expression. % matches any run of characters, _ a single one, and a
+// backslash makes the next character literal.
+func likePattern(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if caseInsensitive {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("(?s)^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compareValues orders two values. Numbers compare numerically, booleans
+// false before true, and strings as dates when both are dates, otherwise
+// lexically. ok is false for NULL and for values of different kinds.
+func compareValues(a, b any) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+
+	if isNumber(a) || isNumber(b) {
+		x, okA := toFloat64(a)
+		y, okB := toFloat64(b)
+		if !okA || !okB {
+			return 0, false
+		}
+		return compareOrdered(x, y), true
+	}
+
+	switch x := a.(type) {
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case !x:
+			return -1, true
+		default:
+			return 1, true
+		}
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		if tx, okX := toTime(x); okX {
+			if ty, okY := toTime(y); okY {
+				return tx.Compare(ty), true
+			}
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
+
+func isNumber(value any) bool {
+	switch value.(type) {
+	case float64, float32, int, int64, json.Number:
+		return true
+	}
+	return false
+}
+
+func compareOrdered(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// sortRows orders rows by the sort options. NULLs sort last in ascending
+// order and first in descending order, as in PostgreSQL.
+func sortRows(rows []map[string]any, sortOptions []models.SortOption) {
+	if len(sortOptions) == 0 {
+		return
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, option := range sortOptions {
+			a, b := rows[i][option.Field], rows[j][option.Field]
+
+			var n int
+			switch {
+			case a == nil && b == nil:
+				n = 0
+			case a == nil:
+				n = 1
+			case b == nil:
+				n = -1
+			default:
+				n, _ = compareValues(a, b)
+			}
+
+			if strings.EqualFold(option.Direction, "desc") {
+				n = -n
+			}
+			if n != 0 {
+				return n < 0
+			}
+		}
+		return false
+	})
+}
+
+// paginateRows returns one page of rows, using the same offset as composeQuery
+func paginateRows(rows []map[string]any, pagination *models.PaginationOptions) []map[string]any {
+	if pagination == nil {
+		return rows
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(rows) || pagination.PageSize <= 0 {
+		return nil
+	}
+
+	end := offset + pagination.PageSize
+	if end > len(rows) {
+		end = len(rows)
+	}
+	return rows[offset:end]
+}
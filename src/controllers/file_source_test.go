@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"axis/src/storage"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFileConnector writes the given files into a temporary directory and
+// returns a file connector over it
+func newFileConnector(t *testing.T, files map[string]string) *models.Connector {
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return &models.Connector{ID: "files", Type: fileConnectorType, Config: models.DatabaseConfig{Path: dir}}
+}
+
+// allowTestDataDir lets file and sqlite connectors read the temporary
+// directories tests create
+func allowTestDataDir(t *testing.T) {
+	SetConnectorDataDir(os.TempDir())
+	t.Cleanup(func() { SetConnectorDataDir("") })
+}
+
+func readSource(t *testing.T, source rowSource) []map[string]any {
+	var rows []map[string]any
+	for source.next() {
+		row, err := source.row()
+		assert.NoError(t, err)
+		rows = append(rows, row)
+	}
+	assert.NoError(t, source.err())
+	return rows
+}
+
+const cityCSV = "\ufeffname,country_code,population,capital\n" +
+	"Oslo,NOR,508726,true\n" +
+	"Bergen,NOR,230948,false\n" +
+	"Stockholm,SWE,750348,true\n" +
+	"\"Lund, Skåne\",SWE,,false\n"
+
+var cityFields = []models.FieldDefinition{
+	{Name: "name", Type: models.FieldTypeString, Filterable: true, Sortable: true},
+	{Name: "population", Type: models.FieldTypeInteger, Filterable: true, Sortable: true},
+	{Name: "capital", Type: models.FieldTypeBoolean, Filterable: true},
+}
+
+func TestFileSource_CSV(t *testing.T) {
+	connector := newFileConnector(t, map[string]string{"city.csv": cityCSV})
+
+	source, err := openFileSource(context.Background(), connector, models.DatabaseQuery{Table: "city", Fields: cityFields})
+	assert.NoError(t, err)
+	rows := readSource(t, source)
+
+	assert.Len(t, rows, 4)
+	assert.Equal(t, map[string]any{"name": "Oslo", "country_code": "NOR", "population": int64(508726), "capital": true}, rows[0])
+	assert.Equal(t, "Lund, Skåne", rows[3]["name"])
+	assert.Nil(t, rows[3]["population"])
+}
+
+func TestFileSource_FilterSortPaginate(t *testing.T) {
+	connector := newFileConnector(t, map[string]string{"city.csv": cityCSV})
+
+	names := func(query models.DatabaseQuery) []any {
+		query.Table = "city"
+		query.Fields = cityFields
+		source, err := openFileSource(context.Background(), connector, query)
+		assert.NoError(t, err)
+
+		var result []any
+		for _, row := range readSource(t, source) {
+			result = append(result, row["name"])
+		}
+		return result
+	}
+
+	assert.Equal(t, []any{"Oslo", "Stockholm"}, names(models.DatabaseQuery{
+		Filters: []models.FilterCondition{{Field: "population", Operator: models.OperatorGreater, Value: float64(300000)}},
+	}))
+	assert.Equal(t, []any{"Bergen", "Lund, Skåne"}, names(models.DatabaseQuery{
+		Filters: []models.FilterCondition{{Field: "capital", Operator: models.OperatorEquals, Value: false}},
+	}))
+	assert.Equal(t, []any{"Lund, Skåne"}, names(models.DatabaseQuery{
+		Filters: []models.FilterCondition{{Field: "name", Operator: models.OperatorILike, Value: "l_nd%"}},
+	}))
+	assert.Equal(t, []any{"Oslo", "Bergen"}, names(models.DatabaseQuery{
+		Filters: []models.FilterCondition{{Field: "country_code", Operator: models.OperatorIn, Value: []any{"NOR", "DNK"}}},
+	}))
+
+	// NULLs sort last in ascending order
+	assert.Equal(t, []any{"Bergen", "Oslo", "Stockholm", "Lund, Skåne"}, names(models.DatabaseQuery{
+		Sort: []models.SortOption{{Field: "population", Direction: "asc"}},
+	}))
+	assert.Equal(t, []any{"Oslo", "Bergen"}, names(models.DatabaseQuery{
+		Sort:       []models.SortOption{{Field: "population", Direction: "desc"}},
+		Pagination: &models.PaginationOptions{Page: 2, PageSize: 2},
+	}))
+	assert.Empty(t, names(models.DatabaseQuery{Pagination: &models.PaginationOptions{Page: 5, PageSize: 2}}))
+}
+
+func TestFileSource_JSONLines(t *testing.T) {
+	connector := newFileConnector(t, map[string]string{
+		"country.jsonl": `{"code": "NOR", "name": "Norway", "gnp": 145895.00, "languages": ["Norwegian"]}` + "\n\n" +
+			`{"code": "SWE", "name": "Sweden", "gnp": 226492.00, "languages": ["Swedish", "Finnish"]}` + "\n",
+	})
+
+	source, err := openFileSource(context.Background(), connector, models.DatabaseQuery{
+		Table:   "country",
+		Filters: []models.FilterCondition{{Field: "gnp", Operator: models.OperatorGreater, Value: float64(200000)}},
+	})
+	assert.NoError(t, err)
+	rows := readSource(t, source)
+
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "Sweden", rows[0]["name"])
+	assert.Equal(t, json.Number("226492.00"), rows[0]["gnp"])
+	assert.Equal(t, []any{"Swedish", "Finnish"}, rows[0]["languages"])
+}
+
+func TestFileSource_TableNames(t *testing.T) {
+	connector := newFileConnector(t, map[string]string{"city.csv": cityCSV})
+
+	for _, table := range []string{"", "../city", "sub/city", ".hidden", ".."} {
+		_, err := openFileSource(context.Background(), connector, models.DatabaseQuery{Table: table})
+		assert.Error(t, err, table)
+		assert.Equal(t, http.StatusBadRequest, err.(*sourceError).status, table)
+	}
+
+	_, err := openFileSource(context.Background(), connector, models.DatabaseQuery{Table: "country"})
+	assert.Equal(t, http.StatusNotFound, err.(*sourceError).status)
+}
+
+func TestExecuteContract_FileConnector(t *testing.T) {
+	defer SetContractStore(contractStore)
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetContractStore(store)
+	SetConnectorStore(store)
+
+	connector := newFileConnector(t, map[string]string{"city.csv": cityCSV})
+	assert.NoError(t, store.SaveConnector(connector))
+	assert.NoError(t, store.SaveContract(&models.Contract{
+		ID: "file-contract",
+		Query: models.DatabaseQuery{
+			ConnectorID: connector.ID,
+			Table:       "city",
+			Fields:      cityFields,
+		},
+		ResponseTemplate: models.ResponseTemplate{
+			Mode:          models.TemplateModeTyped,
+			Template:      map[string]any{"name": "{{.name}}", "population": "{{.population}}"},
+			Anonymization: []models.AnonymizationRule{{Field: "name", Method: "mask", Pattern: "XX**"}},
+		},
+	}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contracts/:id/execute", ExecuteContract)
+
+	body := `{"filters": [{"field": "capital", "operator": "eq", "value": true}], "sort": [{"field": "population", "direction": "desc"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/contracts/file-contract/execute", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Results []map[string]any `json:"results"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []map[string]any{
+		{"name": "St**", "population": float64(750348)},
+		{"name": "Os**", "population": float64(508726)},
+	}, response.Results)
+
+	// Invalid pages are refused before any source is read
+	req = httptest.NewRequest(http.MethodPost, "/contracts/file-contract/execute", strings.NewReader(`{"pagination": {"page": 1, "pageSize": -5}}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestValidateConnectorPath(t *testing.T) {
+	dir := t.TempDir()
+	SetConnectorDataDir(dir)
+	defer SetConnectorDataDir("")
+
+	for _, path := range []string{dir, filepath.Join(dir, "world.db"), filepath.Join(dir, "drops", "partner")} {
+		assert.NoError(t, validateConnectorPath(models.Connector{Type: "sqlite", Config: models.DatabaseConfig{Path: path}}), path)
+		assert.NoError(t, validateConnectorPath(models.Connector{Type: fileConnectorType, Config: models.DatabaseConfig{Path: path}}), path)
+	}
+	for _, path := range []string{"", "/etc/passwd", filepath.Dir(dir), filepath.Join(dir, "..", "other.db"), dir + "-other/world.db", "world.db"} {
+		assert.Error(t, validateConnectorPath(models.Connector{Type: "sqlite", Config: models.DatabaseConfig{Path: path}}), path)
+		assert.Error(t, validateConnectorPath(models.Connector{Type: fileConnectorType, Config: models.DatabaseConfig{Path: path}}), path)
+	}
+
+	// Other connector types have no path
+	assert.NoError(t, validateConnectorPath(models.Connector{Type: "postgres", Config: models.DatabaseConfig{Path: "/etc/passwd"}}))
+
+	// Without a data directory no path is allowed
+	SetConnectorDataDir("")
+	assert.Error(t, validateConnectorPath(models.Connector{Type: "sqlite", Config: models.DatabaseConfig{Path: filepath.Join(dir, "world.db")}}))
+}
+
+func TestSaveConnector_RefusesPathsOutsideDataDir(t *testing.T) {
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+	SetConnectorDataDir(t.TempDir())
+	defer SetConnectorDataDir("")
+
+	outside := newSQLiteDatabase(t, "CREATE TABLE t (x INTEGER)")
+	assert.NoError(t, store.SaveConnector(&models.Connector{ID: "world", Type: "sqlite", Config: models.DatabaseConfig{Path: connectorDataDir}}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/connectors", CreateConnector)
+	router.PUT("/connectors/:id", UpdateConnector)
+	router.POST("/connectors/test", TestConnectorDefinition)
+
+	for _, request := range []struct{ method, url, body string }{
+		{http.MethodPost, "/connectors", `{"type": "sqlite", "config": {"path": "` + outside + `"}}`},
+		{http.MethodPut, "/connectors/world", `{"type": "sqlite", "config": {"path": "` + outside + `"}}`},
+		{http.MethodPost, "/connectors/test", `{"type": "sqlite", "config": {"path": "` + outside + `"}}`},
+		{http.MethodPost, "/connectors", `{"type": "file", "config": {"path": "/etc"}}`},
+		{http.MethodPost, "/connectors/test", `{"type": "file", "config": {"path": "` + connectorDataDir + `/../.."}}`},
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(request.method, request.url, strings.NewReader(request.body)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, request.body)
+		assert.Contains(t, rr.Body.String(), "is not allowed")
+	}
+
+	stored, err := store.LoadConnector("world")
+	assert.NoError(t, err)
+	assert.Equal(t, connectorDataDir, stored.Config.Path)
+	connectors, err := store.ListConnectors()
+	assert.NoError(t, err)
+	assert.Len(t, connectors, 1)
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"encoding/json"
+)
+
+// rowRenderer turns result rows into response objects. Without groupBy every
+// row is rendered on its own; with groupBy rows are merged per parent key and
+// the finished parents are returned by flush, in order of first appearance.
+type rowRenderer struct {
+	tmpl   *compiledTemplate
+	groups map[string]*rowGroup
+	order  []*rowGroup
+
+	// contiguous emits a parent as soon as a row with another key arrives,
+	// keeping memory flat when rows are ordered by the groupBy columns
+	contiguous bool
+	lastKey    string
+}
+
+type rowGroup struct {
+	first     map[string]any
+	collected map[*templateNode][]any
+	seen      map[*templateNode]map[string]bool
+}
+
+func newRowRenderer(tmpl *compiledTemplate) *rowRenderer {
+	return &rowRenderer{tmpl: tmpl, groups: make(map[string]*rowGroup)}
+}
+
+// add renders a row, or merges it into its group when the template groups rows
+func (r *rowRenderer) add(row map[string]any) ([]map[string]any, error) {
+	if len(r.tmpl.groupBy) == 0 {
+		result, err := r.tmpl.render(row)
+		if err != nil {
+			return nil, err
+		}
+		return []map[string]any{result}, nil
+	}
+
+	key, err := r.groupKey(row)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []map[string]any
+	if r.contiguous && len(r.order) > 0 && key != r.lastKey {
+		if done, err = r.flush(); err != nil {
+			return nil, err
+		}
+	}
+	r.lastKey = key
+
+	group, ok := r.groups[key]
+	if !ok {
+		group = &rowGroup{
+			first:     row,
+			collected: make(map[*templateNode][]any),
+			seen:      make(map[*templateNode]map[string]bool),
+		}
+		r.groups[key] = group
+		r.order = append(r.order, group)
+	}
+
+	return done, r.collect(group, row)
+}
+
+// flush returns the parents assembled so far and forgets them
+func (r *rowRenderer) flush() ([]map[string]any, error) {
+	results := make([]map[string]any, 0, len(r.order))
+	for _, group := range r.order {
+		result, err := r.tmpl.renderGroup(group.first, group.collected)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	r.groups = make(map[string]*rowGroup)
+	r.order = nil
+	return results, nil
+}
+
+func (r *rowRenderer) groupKey(row map[string]any) (string, error) {
+	values := make([]any, len(r.tmpl.groupBy))
+	for i, col := range r.tmpl.groupBy {
+		values[i] = row[col]
+	}
+	key, err := json.Marshal(values)
+	return string(key), err
+}
+
+// collect appends the row's children to every collect node of its group.
+// Rows whose child columns are all NULL, as produced by a LEFT JOIN without
+// a match, add nothing. Distinct collect nodes keep identical children once,
+// so joining several child tables does not multiply entries; others keep
+// every row, as different rows may render alike.
+func (r *rowRenderer) collect(group *rowGroup, row map[string]any) error {
+	var data any = row
+	if r.tmpl.typed {
+		data = interpolationData(row)
+	}
+
+	for _, node := range r.tmpl.collects {
+		if allNull(row, node.columns) {
+			continue
+		}
+
+		child, err := node.child.render(row, data, nil)
+		if err != nil {
+			return err
+		}
+
+		if node.distinct {
+			fingerprint, err := json.Marshal(child)
+			if err != nil {
+				return err
+			}
+			if group.seen[node] == nil {
+				group.seen[node] = make(map[string]bool)
+			}
+			if group.seen[node][string(fingerprint)] {
+				continue
+			}
+			group.seen[node][string(fingerprint)] = true
+		}
+		group.collected[node] = append(group.collected[node], child)
+	}
+	return nil
+}
+
+func allNull(row map[string]any, columns []string) bool {
+	if len(columns) == 0 {
+		return false
+	}
+	for _, col := range columns {
+		if row[col] != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowRenderer_GroupsChildrenPerParent(t *testing.T) {
+	var template map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"code": "{{.country_code}}",
+		"name": "{{.country_name}}",
+		"cities": {"$collect": {"name": "{{.city_name}}", "population": "{{.city_population}}"}}
+	}`), &template))
+
+	tmpl, err := compileTemplate(models.ResponseTemplate{
+		Mode:     models.TemplateModeTyped,
+		GroupBy:  []string{"country_code"},
+		Template: template,
+	})
+	assert.NoError(t, err)
+
+	rows := []map[string]any{
+		{"country_code": "NOR", "country_name": "Norway", "city_name": "Oslo", "city_population": int64(508726)},
+		{"country_code": "SWE", "country_name": "Sweden", "city_name": "Stockholm", "city_population": int64(750348)},
+		{"country_code": "NOR", "country_name": "Norway", "city_name": "Bergen", "city_population": int64(230948)},
+		{"country_code": "NOR", "country_name": "Norway", "city_name": "Oslo", "city_population": int64(508726)},
+		{"country_code": "ATA", "country_name": "Antarctica", "city_name": nil, "city_population": nil},
+	}
+
+	renderer := newRowRenderer(tmpl)
+	for _, row := range rows {
+		rendered, err := renderer.add(row)
+		assert.NoError(t, err)
+		assert.Empty(t, rendered)
+	}
+
+	results, err := renderer.flush()
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(results)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"code": "NOR", "name": "Norway", "cities": [
+			{"name": "Oslo", "population": 508726},
+			{"name": "Bergen", "population": 230948},
+			{"name": "Oslo", "population": 508726}
+		]},
+		{"code": "SWE", "name": "Sweden", "cities": [
+			{"name": "Stockholm", "population": 750348}
+		]},
+		{"code": "ATA", "name": "Antarctica", "cities": []}
+	]`, string(data))
+}
+
+func TestRowRenderer_DistinctChildren(t *testing.T) {
+	tmpl, err := compileTemplate(models.ResponseTemplate{
+		GroupBy: []string{"order_id"},
+		Template: map[string]any{
+			"id":       "{{.order_id}}",
+			"products": map[string]any{"$collect": "{{.product}}", "distinct": true},
+			"lines":    map[string]any{"$collect": map[string]any{"product": "{{.product}}", "quantity": "{{.quantity}}"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	// Two order lines for the same product and quantity
+	renderer := newRowRenderer(tmpl)
+	for i := 0; i < 2; i++ {
+		_, err := renderer.add(map[string]any{"order_id": "1", "product": "pen", "quantity": "2"})
+		assert.NoError(t, err)
+	}
+
+	results, err := renderer.flush()
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"pen"}, results[0]["products"])
+	assert.Len(t, results[0]["lines"], 2)
+}
+
+func TestRowRenderer_WithoutGrouping(t *testing.T) {
+	tmpl, err := compileTemplate(models.ResponseTemplate{Template: map[string]any{"name": "{{.name}}"}})
+	assert.NoError(t, err)
+
+	renderer := newRowRenderer(tmpl)
+	rendered, err := renderer.add(map[string]any{"name": "Oslo"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"name": "Oslo"}}, rendered)
+
+	flushed, err := renderer.flush()
+	assert.NoError(t, err)
+	assert.Empty(t, flushed)
+}
+
+func TestCompileTemplate_CollectRules(t *testing.T) {
+	collect := map[string]any{"cities": map[string]any{"$collect": map[string]any{"name": "{{.city}}"}}}
+
+	_, err := compileTemplate(models.ResponseTemplate{Template: collect})
+	assert.Error(t, err, "$collect without groupBy")
+
+	nested := map[string]any{"cities": map[string]any{"$collect": map[string]any{
+		"streets": map[string]any{"$collect": "{{.street}}"},
+	}}}
+	_, err = compileTemplate(models.ResponseTemplate{GroupBy: []string{"code"}, Template: nested})
+	assert.Error(t, err, "nested $collect")
+
+	_, err = compileTemplate(models.ResponseTemplate{GroupBy: []string{"code"}, Template: collect})
+	assert.NoError(t, err)
+
+	for _, directive := range []map[string]any{
+		{"$collect": "{{.city}}", "distinct": "yes"},
+		{"$collect": "{{.city}}", "name": "{{.city}}"},
+	} {
+		_, err = compileTemplate(models.ResponseTemplate{GroupBy: []string{"code"}, Template: map[string]any{"cities": directive}})
+		assert.Error(t, err, directive)
+	}
+}
+
+func TestRowRenderer_ContiguousEmitsOnKeyChange(t *testing.T) {
+	tmpl, err := compileTemplate(models.ResponseTemplate{
+		GroupBy: []string{"code"},
+		Template: map[string]any{
+			"code":   "{{.code}}",
+			"cities": map[string]any{"$collect": "{{.city}}"},
+		},
+	})
+	assert.NoError(t, err)
+
+	renderer := newRowRenderer(tmpl)
+	renderer.contiguous = true
+
+	rendered, err := renderer.add(map[string]any{"code": "NOR", "city": "Oslo"})
+	assert.NoError(t, err)
+	assert.Empty(t, rendered)
+
+	rendered, err = renderer.add(map[string]any{"code": "NOR", "city": "Bergen"})
+	assert.NoError(t, err)
+	assert.Empty(t, rendered)
+
+	rendered, err = renderer.add(map[string]any{"code": "SWE", "city": "Stockholm"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"code": "NOR", "cities": []any{"Oslo", "Bergen"}}}, rendered)
+
+	rendered, err = renderer.flush()
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"code": "SWE", "cities": []any{"Stockholm"}}}, rendered)
+}
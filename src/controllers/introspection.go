@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"axis/src/dialect"
+	"axis/src/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSchemas returns the schemas of a SQL connector's database
+func ListSchemas(c *gin.Context) {
+	catalog, ok := openCatalog(c)
+	if !ok {
+		return
+	}
+	defer catalog.cancel()
+
+	schemas := []string{}
+	err := catalog.query(catalog.d.Catalog().Schemas, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		schemas = append(schemas, name)
+		return nil
+	})
+	if err != nil {
+		catalog.fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas)
+}
+
+// ListTables returns the tables and views of a schema, by default the one
+// the connector's user works in
+func ListTables(c *gin.Context) {
+	catalog, ok := openCatalog(c)
+	if !ok {
+		return
+	}
+	defer catalog.cancel()
+
+	schema, err := catalog.schema(c.Query("schema"))
+	if err != nil {
+		catalog.fail(c, err)
+		return
+	}
+
+	tables := []models.TableInfo{}
+	err = catalog.query(catalog.d.Catalog().Tables, func(rows *sql.Rows) error {
+		table := models.TableInfo{Schema: schema}
+		if err := rows.Scan(&table.Name, &table.Type); err != nil {
+			return err
+		}
+		tables = append(tables, table)
+		return nil
+	}, schema)
+	if err != nil {
+		catalog.fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tables)
+}
+
+// ListColumns returns the columns of a table with their types, nullability
+// and primary key membership
+func ListColumns(c *gin.Context) {
+	catalog, ok := openCatalog(c)
+	if !ok {
+		return
+	}
+	defer catalog.cancel()
+
+	schema, err := catalog.schema(c.Query("schema"))
+	if err != nil {
+		catalog.fail(c, err)
+		return
+	}
+
+	columns := []models.ColumnInfo{}
+	err = catalog.query(catalog.d.Catalog().Columns, func(rows *sql.Rows) error {
+		var column models.ColumnInfo
+		var nullable, primaryKey int
+		if err := rows.Scan(&column.Name, &column.DataType, &nullable, &primaryKey); err != nil {
+			return err
+		}
+		column.Nullable = nullable == 1
+		column.PrimaryKey = primaryKey == 1
+		columns = append(columns, column)
+		return nil
+	}, schema, c.Param("table"))
+	if err != nil {
+		catalog.fail(c, err)
+		return
+	}
+	if len(columns) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Table not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, columns)
+}
+
+// catalogSession runs catalog queries against one connector within its timeout
+type catalogSession struct {
+	connector *models.Connector
+	d         dialect.Dialect
+	db        *sql.DB
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// openCatalog loads the connector named in the request and takes its pool.
+// It answers the request itself when that is not possible.
+func openCatalog(c *gin.Context) (*catalogSession, bool) {
+	connector, err := loadConnector(c.Param("id"))
+	if err != nil {
+		if err.Error() == "connector not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Connector not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load connector"})
+		}
+		return nil, false
+	}
+
+	d, ok := dialect.Lookup(connector.Type)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Connector type %q does not support introspection", connector.Type)})
+		return nil, false
+	}
+
+	db, err := pools.get(connector, d)
+	if err != nil {
+		logConnectorError(connector, "opening pool", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection failed"})
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), connectTimeout(connector))
+	return &catalogSession{connector: connector, d: d, db: db, ctx: ctx, cancel: cancel}, true
+}
+
+// schema returns the requested schema, or the connection's current one
+func (s *catalogSession) schema(requested string) (string, error) {
+	if requested != "" {
+		return requested, nil
+	}
+	var current sql.NullString
+	if err := s.db.QueryRowContext(s.ctx, s.d.Catalog().CurrentSchema).Scan(&current); err != nil {
+		return "", err
+	}
+	return current.String, nil
+}
+
+func (s *catalogSession) query(statement string, scan func(*sql.Rows) error, args ...any) error {
+	rows, err := s.db.QueryContext(s.ctx, statement, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *catalogSession) fail(c *gin.Context, err error) {
+	if ctxErr := contextError(s.ctx); ctxErr != nil {
+		respondSourceError(c, ctxErr)
+		return
+	}
+	logConnectorError(s.connector, "reading catalog", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read database catalog"})
+}
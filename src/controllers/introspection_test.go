@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"axis/src/storage"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospection_SQLite(t *testing.T) {
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+
+	path := newSQLiteDatabase(t,
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT NOT NULL, population INTEGER)",
+		"CREATE TABLE country (code TEXT, name TEXT, PRIMARY KEY (code))",
+		"CREATE VIEW big_city AS SELECT name FROM city WHERE population > 1000000",
+	)
+	assert.NoError(t, store.SaveConnector(&models.Connector{ID: "world", Type: "sqlite", Config: models.DatabaseConfig{Path: path}}))
+	assert.NoError(t, store.SaveConnector(&models.Connector{ID: "drops", Type: fileConnectorType}))
+	defer pools.invalidate("world")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/connectors/:id/schemas", ListSchemas)
+	router.GET("/connectors/:id/tables", ListTables)
+	router.GET("/connectors/:id/tables/:table/columns", ListColumns)
+	get := func(url string, v any) int {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		if v != nil && rr.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), v))
+		}
+		return rr.Code
+	}
+
+	var schemas []string
+	assert.Equal(t, http.StatusOK, get("/connectors/world/schemas", &schemas))
+	assert.Equal(t, []string{"main"}, schemas)
+
+	var tables []models.TableInfo
+	assert.Equal(t, http.StatusOK, get("/connectors/world/tables", &tables))
+	assert.Equal(t, []models.TableInfo{
+		{Schema: "main", Name: "big_city", Type: "view"},
+		{Schema: "main", Name: "city", Type: "table"},
+		{Schema: "main", Name: "country", Type: "table"},
+	}, tables)
+
+	var columns []models.ColumnInfo
+	assert.Equal(t, http.StatusOK, get("/connectors/world/tables/city/columns?schema=main", &columns))
+	assert.Equal(t, []models.ColumnInfo{
+		{Name: "id", DataType: "INTEGER", Nullable: false, PrimaryKey: true},
+		{Name: "name", DataType: "TEXT", Nullable: false},
+		{Name: "population", DataType: "INTEGER", Nullable: true},
+	}, columns)
+
+	assert.Equal(t, http.StatusOK, get("/connectors/world/tables/country/columns", &columns))
+	assert.True(t, columns[0].PrimaryKey)
+	assert.False(t, columns[1].PrimaryKey)
+
+	assert.Equal(t, http.StatusNotFound, get("/connectors/world/tables/missing/columns", nil))
+	assert.Equal(t, http.StatusNotFound, get("/connectors/nope/tables", nil))
+	assert.Equal(t, http.StatusBadRequest, get("/connectors/drops/schemas", nil))
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resultWriter receives the rendered results of a contract execution
+type resultWriter interface {
+	// streaming reports whether results reach the client as they are written
+	streaming() bool
+	write(result map[string]any) error
+	// fail reports an error; once streaming has started it ends the stream
+	fail(status int, message string)
+	finish()
+}
+
+// outputFormats are the values ?format= accepts
+var outputFormats = map[string]bool{
+	"": true, "json": true, "ndjson": true, "csv": true, "tsv": true, "parquet": true, "arrow": true,
+}
+
+// validateOutputFormat rejects a ?format= that names no supported format, so
+// a typo is not answered with the default JSON document
+func validateOutputFormat(c *gin.Context) error {
+	if format := c.Query("format"); !outputFormats[format] {
+		return fmt.Errorf("unknown format %q; use json, ndjson, csv, tsv, parquet or arrow", format)
+	}
+	return nil
+}
+
+// newResultWriter picks the output format requested by the client, either
+// with ?format= or through the Accept header
+func newResultWriter(c *gin.Context, contractID string, tmpl *compiledTemplate, columnTypes []*sql.ColumnType) resultWriter {
+	accept := c.GetHeader("Accept")
+	format := c.Query("format")
+	switch {
+	case format == "json":
+		return &envelopeWriter{c: c, contractID: contractID, results: make([]map[string]any, 0)}
+	case format == "parquet" || (format == "" && strings.Contains(accept, contentTypeParquet)):
+		return newColumnarWriter(c, tmpl, columnTypes, contentTypeParquet)
+	case format == "arrow" || (format == "" && strings.Contains(accept, contentTypeArrow)):
+		return newColumnarWriter(c, tmpl, columnTypes, contentTypeArrow)
+	case format == "csv" || (format == "" && strings.Contains(accept, "text/csv")):
+		return newDelimitedWriter(c, tmpl, ',', "text/csv")
+	case format == "tsv" || (format == "" && strings.Contains(accept, "text/tab-separated-values")):
+		return newDelimitedWriter(c, tmpl, '\t', "text/tab-separated-values")
+	case format == "ndjson" || c.Query("stream") == "true" || strings.Contains(accept, "application/x-ndjson"):
+		return &ndjsonWriter{c: c, contractID: contractID}
+	}
+	return &envelopeWriter{c: c, contractID: contractID, results: make([]map[string]any, 0)}
+}
+
+// envelopeWriter collects every result into a single JSON document
+type envelopeWriter struct {
+	c          *gin.Context
+	contractID string
+	results    []map[string]any
+}
+
+func (w *envelopeWriter) streaming() bool { return false }
+
+func (w *envelopeWriter) write(result map[string]any) error {
+	w.results = append(w.results, result)
+	return nil
+}
+
+func (w *envelopeWriter) fail(status int, message string) {
+	w.c.JSON(status, gin.H{"error": message})
+}
+
+func (w *envelopeWriter) finish() {
+	// Return the response
+	w.c.JSON(http.StatusOK, gin.H{
+		"contract_id": w.contractID,
+		"status":      "success",
+		"results":     w.results,
+		"timestamp":   time.Now().UTC(),
+	})
+}
+
+// ndjsonWriter streams one JSON object per line and flushes every line, so
+// memory use does not grow with the result set. The last line is a trailer,
+// {"_trailer": {...}}, with the final status and the number of records.
+type ndjsonWriter struct {
+	c          *gin.Context
+	contractID string
+	enc        *json.Encoder
+	count      int
+}
+
+func (w *ndjsonWriter) streaming() bool { return true }
+
+func (w *ndjsonWriter) start() {
+	if w.enc != nil {
+		return
+	}
+	w.c.Header("Content-Type", "application/x-ndjson")
+	w.c.Status(http.StatusOK)
+	w.enc = json.NewEncoder(w.c.Writer)
+}
+
+func (w *ndjsonWriter) write(result map[string]any) error {
+	w.start()
+	if err := w.enc.Encode(result); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	w.count++
+	return nil
+}
+
+func (w *ndjsonWriter) fail(status int, message string) {
+	// Nothing sent yet, so the client still gets a regular error response
+	if w.enc == nil {
+		w.c.JSON(status, gin.H{"error": message})
+		return
+	}
+	w.trailer(gin.H{"status": "error", "error": message})
+}
+
+func (w *ndjsonWriter) finish() {
+	w.start()
+	w.trailer(gin.H{"status": "success"})
+}
+
+func (w *ndjsonWriter) trailer(fields gin.H) {
+	fields["contract_id"] = w.contractID
+	fields["rowCount"] = w.count
+	fields["timestamp"] = time.Now().UTC()
+	_ = w.enc.Encode(gin.H{"_trailer": fields})
+	w.c.Writer.Flush()
+}
+
+// delimitedWriter streams results as CSV or TSV. The header row lists the
+// top-level template keys in sorted order; nested values are written as JSON.
+// As a delimited file has no room for a trailer, the outcome is reported in
+// the X-Axis-Status HTTP trailer.
+type delimitedWriter struct {
+	c           *gin.Context
+	w           *csv.Writer
+	columns     []string
+	contentType string
+	started     bool
+}
+
+func newDelimitedWriter(c *gin.Context, tmpl *compiledTemplate, comma rune, contentType string) *delimitedWriter {
+	w := csv.NewWriter(c.Writer)
+	w.Comma = comma
+	return &delimitedWriter{c: c, w: w, columns: tmpl.root.keys, contentType: contentType}
+}
+
+func (w *delimitedWriter) streaming() bool { return true }
+
+func (w *delimitedWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	w.c.Header("Content-Type", w.contentType+"; charset=utf-8")
+	w.c.Header("Trailer", "X-Axis-Status")
+	w.c.Status(http.StatusOK)
+	return w.w.Write(w.columns)
+}
+
+func (w *delimitedWriter) write(result map[string]any) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(w.columns))
+	for i, col := range w.columns {
+		record[i] = formatCell(result[col])
+	}
+	if err := w.w.Write(record); err != nil {
+		return err
+	}
+
+	w.w.Flush()
+	w.c.Writer.Flush()
+	return w.w.Error()
+}
+
+func (w *delimitedWriter) fail(status int, message string) {
+	if !w.started {
+		w.c.JSON(status, gin.H{"error": message})
+		return
+	}
+	w.w.Flush()
+	w.c.Writer.Header().Set("X-Axis-Status", "error: "+message)
+}
+
+func (w *delimitedWriter) finish() {
+	_ = w.start()
+	w.w.Flush()
+	w.c.Writer.Header().Set("X-Axis-Status", "success")
+}
+
+// formatCell renders a result value as the text of a single CSV field
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOutputContext(target string, accept string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	return c, w
+}
+
+func testTemplate(t *testing.T) *compiledTemplate {
+	tmpl, err := compileTemplate(models.ResponseTemplate{
+		Mode: models.TemplateModeTyped,
+		Template: map[string]any{
+			"name":       "{{.name}}",
+			"population": "{{.population}}",
+			"address":    map[string]any{"district": "{{.district}}"},
+		},
+	})
+	assert.NoError(t, err)
+	return tmpl
+}
+
+func TestNewResultWriter_Negotiation(t *testing.T) {
+	c, _ := newOutputContext("/contracts/x/execute", "")
+	assert.IsType(t, &envelopeWriter{}, newResultWriter(c, "x", testTemplate(t), nil))
+
+	c, _ = newOutputContext("/contracts/x/execute?stream=true", "")
+	assert.IsType(t, &ndjsonWriter{}, newResultWriter(c, "x", testTemplate(t), nil))
+
+	c, _ = newOutputContext("/contracts/x/execute", "application/x-ndjson")
+	assert.IsType(t, &ndjsonWriter{}, newResultWriter(c, "x", testTemplate(t), nil))
+
+	c, _ = newOutputContext("/contracts/x/execute", "text/csv")
+	assert.IsType(t, &delimitedWriter{}, newResultWriter(c, "x", testTemplate(t), nil))
+
+	c, _ = newOutputContext("/contracts/x/execute?format=tsv", "application/json")
+	assert.IsType(t, &delimitedWriter{}, newResultWriter(c, "x", testTemplate(t), nil))
+
+	c, _ = newOutputContext("/contracts/x/execute?format=json", "application/x-ndjson")
+	assert.IsType(t, &envelopeWriter{}, newResultWriter(c, "x", testTemplate(t), nil))
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, format := range []string{"", "json", "ndjson", "csv", "tsv", "parquet", "arrow"} {
+		c, _ := newOutputContext("/contracts/x/execute?format="+format, "")
+		assert.NoError(t, validateOutputFormat(c), format)
+	}
+
+	for _, format := range []string{"xlsx", "json2", "CSV"} {
+		c, _ := newOutputContext("/contracts/x/execute?format="+format, "")
+		assert.Error(t, validateOutputFormat(c), format)
+	}
+
+	// The format is checked before the contract is even loaded
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contracts/:id/execute", ExecuteContract)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/contracts/missing/execute?format=xlsx", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `unknown format \"xlsx\"`)
+}
+
+func TestDelimitedWriter_CSV(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute?format=csv", "")
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+
+	assert.NoError(t, out.write(map[string]any{
+		"name":       "O'Brien, \"Jr\"",
+		"population": json.Number("1200"),
+		"address":    map[string]any{"district": "North"},
+	}))
+	assert.NoError(t, out.write(map[string]any{"name": "Oslo", "population": nil, "address": map[string]any{"district": nil}}))
+	out.finish()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "address,name,population\n"+
+		`"{""district"":""North""}","O'Brien, ""Jr""",1200`+"\n"+
+		`"{""district"":null}",Oslo,`+"\n", w.Body.String())
+	assert.Equal(t, "success", w.Header().Get("X-Axis-Status"))
+}
+
+func TestDelimitedWriter_TSVWithoutRows(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute", "text/tab-separated-values")
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+	out.finish()
+
+	assert.Equal(t, "address\tname\tpopulation\n", w.Body.String())
+}
+
+func TestNDJSONWriter_StreamsRowsAndTrailer(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute?stream=true", "")
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+
+	assert.NoError(t, out.write(map[string]any{"name": "Oslo"}))
+	assert.NoError(t, out.write(map[string]any{"name": "Bergen"}))
+	out.finish()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.JSONEq(t, `{"name": "Oslo"}`, lines[0])
+	assert.JSONEq(t, `{"name": "Bergen"}`, lines[1])
+
+	var trailer map[string]map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &trailer))
+	assert.Equal(t, "success", trailer["_trailer"]["status"])
+	assert.Equal(t, 2.0, trailer["_trailer"]["rowCount"])
+}
+
+func TestNDJSONWriter_ErrorAfterRows(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute?stream=true", "")
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+
+	assert.NoError(t, out.write(map[string]any{"name": "Oslo"}))
+	out.fail(http.StatusInternalServerError, "Error scanning row")
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"status":"error"`)
+	assert.Contains(t, lines[1], `"error":"Error scanning row"`)
+}
+
+func TestNDJSONWriter_ErrorBeforeRows(t *testing.T) {
+	c, w := newOutputContext("/contracts/x/execute?stream=true", "")
+	out := newResultWriter(c, "x", testTemplate(t), nil)
+
+	out.fail(http.StatusInternalServerError, "Error reading rows")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "Error reading rows"}`, w.Body.String())
+}
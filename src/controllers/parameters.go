@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var parameterNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateParameterDefinitions checks the parameters declared by a contract
+func validateParameterDefinitions(params []models.ParameterDefinition) error {
+	seen := make(map[string]bool)
+	for _, param := range params {
+		if !parameterNamePattern.MatchString(param.Name) {
+			return fmt.Errorf("parameter name %q must start with a letter or underscore and contain only letters, digits and underscores", param.Name)
+		}
+		if seen[param.Name] {
+			return fmt.Errorf("parameter %q is declared more than once", param.Name)
+		}
+		seen[param.Name] = true
+
+		if !isKnownType(param.Type) {
+			return fmt.Errorf("parameter %q has unknown type %q", param.Name, param.Type)
+		}
+		if param.Default != nil {
+			if msg := checkParameterValue(param, param.Default); msg != "" {
+				return fmt.Errorf("default for parameter %q %s", param.Name, msg)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveParameters validates the caller's parameters against the contract
+// definitions and applies defaults. Every problem is reported, not just the first.
+func resolveParameters(defs []models.ParameterDefinition, provided map[string]any) (map[string]any, []models.ParameterError) {
+	var errs []models.ParameterError
+	resolved := make(map[string]any, len(defs))
+
+	declared := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		declared[def.Name] = true
+
+		value, ok := provided[def.Name]
+		if !ok || value == nil {
+			if def.Required {
+				errs = append(errs, models.ParameterError{Parameter: def.Name, Message: "is required"})
+				continue
+			}
+			value = def.Default
+		}
+
+		if value != nil {
+			if msg := checkParameterValue(def, value); msg != "" {
+				errs = append(errs, models.ParameterError{Parameter: def.Name, Message: msg})
+				continue
+			}
+			// JSON numbers decode as float64; bind integers as integers
+			if n, ok := value.(float64); ok && def.Type == models.FieldTypeInteger {
+				value = int64(n)
+			}
+		}
+		resolved[def.Name] = value
+	}
+
+	var unknown []string
+	for name := range provided {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, models.ParameterError{Parameter: name, Message: "is not declared by this contract"})
+	}
+
+	return resolved, errs
+}
+
+// checkParameterValue returns a description of why the value is invalid, or "" when it is valid
+func checkParameterValue(def models.ParameterDefinition, value any) string {
+	if !matchesType(def.Type, value) {
+		return fmt.Sprintf("must be a valid %s", def.Type)
+	}
+
+	if len(def.Enum) > 0 {
+		allowed := false
+		for _, option := range def.Enum {
+			if option == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("must be one of %v", def.Enum)
+		}
+	}
+
+	if n, ok := value.(float64); ok {
+		if def.Min != nil && n < *def.Min {
+			return fmt.Sprintf("must be at least %v", *def.Min)
+		}
+		if def.Max != nil && n > *def.Max {
+			return fmt.Sprintf("must be at most %v", *def.Max)
+		}
+	}
+
+	if s, ok := value.(string); ok && def.Type == models.FieldTypeString {
+		length := utf8.RuneCountInString(s)
+		if def.MinLength != nil && length < *def.MinLength {
+			return fmt.Sprintf("must be at least %d characters", *def.MinLength)
+		}
+		if def.MaxLength != nil && length > *def.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", *def.MaxLength)
+		}
+	}
+
+	return ""
+}
+
+// bindParameters replaces :name references to declared parameters with bind
+// placeholders for the target database and returns the matching arguments.
+// References to names that are not declared are left untouched.
+func bindParameters(query string, values map[string]any, dbType string) (string, []any) {
+	if len(values) == 0 {
+		return query, nil
+	}
+
+	var b strings.Builder
+	var args []any
+	positions := make(map[string]int)
+	last := 0
+
+	for _, ref := range findNamedParameters(query, dbType) {
+		value, ok := values[ref.name]
+		if !ok {
+			continue
+		}
+
+		b.WriteString(query[last:ref.start])
+		if dialectFor(dbType).NumberedPlaceholders() {
+			// Numbered placeholders can be reused when a parameter appears twice
+			n, seen := positions[ref.name]
+			if !seen {
+				args = append(args, value)
+				n = len(args)
+				positions[ref.name] = n
+			}
+			b.WriteString(placeholder(n, dbType))
+		} else {
+			args = append(args, value)
+			b.WriteString(placeholder(len(args), dbType))
+		}
+		last = ref.end
+	}
+	b.WriteString(query[last:])
+
+	return b.String(), args
+}
+
+type namedParameter struct {
+	name       string
+	start, end int
+}
+
+// findNamedParameters locates :name references in a SQL statement, skipping
+// string literals, quoted identifiers, comments and Postgres :: casts.
+func findNamedParameters(query string, dbType string) []namedParameter {
+	var refs []namedParameter
+	backslashEscapes := dialectFor(dbType).BackslashEscapes()
+
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == '\'' || ch == '"' || ch == '`':
+			i = skipQuoted(query, i, ch, backslashEscapes && ch != '`')
+		case ch == '[' && dbType == "sqlserver":
+			i = skipQuoted(query, i, ']', false)
+		case ch == '-' && strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+		case ch == '$':
+			i = skipDollarQuoted(query, i)
+		case ch == ':':
+			if i+1 < len(query) && query[i+1] == ':' {
+				i++
+				continue
+			}
+			end := i + 1
+			for end < len(query) && isIdentifierChar(query[end], end == i+1) {
+				end++
+			}
+			if end > i+1 {
+				refs = append(refs, namedParameter{name: query[i+1 : end], start: i, end: end})
+				i = end - 1
+			}
+		}
+	}
+	return refs
+}
+
+// skipQuoted returns the index of the quote closing the literal that starts at i
+func skipQuoted(query string, i int, quote byte, backslashEscapes bool) int {
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if backslashEscapes {
+				j++
+			}
+		case quote:
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(query)
+}
+
+// skipDollarQuoted skips a Postgres $tag$...$tag$ string starting at i
+func skipDollarQuoted(query string, i int) int {
+	end := i + 1
+	for end < len(query) && isIdentifierChar(query[end], end == i+1) {
+		end++
+	}
+	if end >= len(query) || query[end] != '$' {
+		return i
+	}
+
+	tag := query[i : end+1]
+	if closing := strings.Index(query[end+1:], tag); closing >= 0 {
+		return end + closing + len(tag)
+	}
+	return len(query)
+}
+
+func isIdentifierChar(ch byte, first bool) bool {
+	if ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') {
+		return true
+	}
+	return !first && ch >= '0' && ch <= '9'
+}
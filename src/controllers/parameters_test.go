@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"axis/src/storage"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(n int) *int { return &n }
+
+func floatPtr(n float64) *float64 { return &n }
+
+func TestResolveParameters(t *testing.T) {
+	defs := []models.ParameterDefinition{
+		{Name: "country_code", Type: models.FieldTypeString, Required: true, MaxLength: intPtr(3)},
+		{Name: "min_population", Type: models.FieldTypeInteger, Default: 0.0, Min: floatPtr(0)},
+		{Name: "continent", Type: models.FieldTypeString, Enum: []any{"Europe", "Asia"}},
+	}
+
+	resolved, errs := resolveParameters(defs, map[string]any{"country_code": "NOR"})
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]any{"country_code": "NOR", "min_population": int64(0), "continent": nil}, resolved)
+
+	_, errs = resolveParameters(defs, map[string]any{
+		"min_population": -5.0,
+		"continent":      "Atlantis",
+		"limit":          10.0,
+	})
+	assert.Equal(t, []models.ParameterError{
+		{Parameter: "country_code", Message: "is required"},
+		{Parameter: "min_population", Message: "must be at least 0"},
+		{Parameter: "continent", Message: "must be one of [Europe Asia]"},
+		{Parameter: "limit", Message: "is not declared by this contract"},
+	}, errs)
+
+	_, errs = resolveParameters(defs, map[string]any{"country_code": "NORW", "min_population": 1.5})
+	assert.Equal(t, []models.ParameterError{
+		{Parameter: "country_code", Message: "must be at most 3 characters"},
+		{Parameter: "min_population", Message: "must be a valid integer"},
+	}, errs)
+}
+
+func TestValidateParameterDefinitions(t *testing.T) {
+	assert.NoError(t, validateParameterDefinitions([]models.ParameterDefinition{{Name: "code", Type: models.FieldTypeString}}))
+	assert.Error(t, validateParameterDefinitions([]models.ParameterDefinition{{Name: "1code", Type: models.FieldTypeString}}))
+	assert.Error(t, validateParameterDefinitions([]models.ParameterDefinition{{Name: "code", Type: "text"}}))
+	assert.Error(t, validateParameterDefinitions([]models.ParameterDefinition{{Name: "n", Type: models.FieldTypeInteger, Default: "zero"}}))
+	assert.Error(t, validateParameterDefinitions([]models.ParameterDefinition{
+		{Name: "code", Type: models.FieldTypeString},
+		{Name: "code", Type: models.FieldTypeString},
+	}))
+}
+
+func TestBindParameters(t *testing.T) {
+	values := map[string]any{"code": "NOR", "min": int64(1000)}
+	query := "SELECT name::text, ':code' AS literal FROM city -- :min\nWHERE country_code = :code AND population > :min OR district = :code"
+
+	bound, args := bindParameters(query, values, "postgres")
+	assert.Equal(t, "SELECT name::text, ':code' AS literal FROM city -- :min\nWHERE country_code = $1 AND population > $2 OR district = $1", bound)
+	assert.Equal(t, []any{"NOR", int64(1000)}, args)
+
+	bound, args = bindParameters(query, values, "mysql")
+	assert.Equal(t, "SELECT name::text, ':code' AS literal FROM city -- :min\nWHERE country_code = ? AND population > ? OR district = ?", bound)
+	assert.Equal(t, []any{"NOR", int64(1000), "NOR"}, args)
+
+	bound, args = bindParameters("SELECT [a:code] FROM t WHERE c = :code OR d = :code", values, "sqlserver")
+	assert.Equal(t, "SELECT [a:code] FROM t WHERE c = @p1 OR d = @p1", bound)
+	assert.Equal(t, []any{"NOR"}, args)
+
+	// Undeclared names and Postgres dollar-quoted bodies are left alone
+	bound, args = bindParameters("SELECT $$:code$$, arr[lo:hi] FROM t WHERE c = :code", values, "postgres")
+	assert.Equal(t, "SELECT $$:code$$, arr[lo:hi] FROM t WHERE c = $1", bound)
+	assert.Equal(t, []any{"NOR"}, args)
+}
+
+func TestExecuteContract_InvalidParameters(t *testing.T) {
+	defer SetContractStore(contractStore)
+	store := storage.NewMemoryStore()
+	SetContractStore(store)
+
+	contract := &models.Contract{
+		ID: "param-contract",
+		Parameters: []models.ParameterDefinition{
+			{Name: "country_code", Type: models.FieldTypeString, Required: true},
+			{Name: "min_population", Type: models.FieldTypeInteger},
+		},
+		Query: models.DatabaseQuery{SQLQuery: "SELECT name FROM city WHERE country_code = :country_code"},
+	}
+	assert.NoError(t, store.SaveContract(contract))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contracts/:id/execute", ExecuteContract)
+
+	body, _ := json.Marshal(map[string]any{"parameters": map[string]any{"min_population": "lots"}})
+	req := httptest.NewRequest(http.MethodPost, "/contracts/param-contract/execute", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var response struct {
+		Error   string                  `json:"error"`
+		Details []models.ParameterError `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "Invalid parameters", response.Error)
+	assert.Len(t, response.Details, 2)
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"axis/src/dialect"
+	"axis/src/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Pool defaults for connectors that do not configure their own
+const (
+	defaultMaxOpenConns    = 10
+	defaultMaxIdleConns    = 2
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+)
+
+// poolRegistry shares one connection pool per connector across requests
+type poolRegistry struct {
+	mu    sync.Mutex
+	pools map[string]*connectorPool
+}
+
+type connectorPool struct {
+	db *sql.DB
+	// fingerprint identifies the settings the pool was opened with, so a
+	// connector changed behind our back still gets a fresh pool
+	fingerprint string
+}
+
+var pools = &poolRegistry{pools: make(map[string]*connectorPool)}
+
+// get returns the pool of a SQL connector, opening it on first use
+func (r *poolRegistry) get(connector *models.Connector, d dialect.Dialect) (*sql.DB, error) {
+	fingerprint, err := poolFingerprint(connector)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pool, ok := r.pools[connector.ID]; ok {
+		if pool.fingerprint == fingerprint {
+			return pool.db, nil
+		}
+		pool.db.Close()
+		delete(r.pools, connector.ID)
+	}
+
+	dsn, err := buildConnectionString(connector.Config, connector.Type)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(d.Driver(), dsn)
+	if err != nil {
+		return nil, err
+	}
+	configurePool(db, connector.Pool)
+
+	r.pools[connector.ID] = &connectorPool{db: db, fingerprint: fingerprint}
+	return db, nil
+}
+
+// invalidate closes the pool of a connector. Queries already running finish
+// on their connections; the next request opens a new pool.
+func (r *poolRegistry) invalidate(connectorID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pool, ok := r.pools[connectorID]; ok {
+		pool.db.Close()
+		delete(r.pools, connectorID)
+	}
+}
+
+func configurePool(db *sql.DB, config *models.PoolConfig) {
+	if config == nil {
+		config = &models.PoolConfig{}
+	}
+
+	maxOpen := config.MaxOpenConns
+	if maxOpen == 0 {
+		maxOpen = defaultMaxOpenConns
+	}
+	maxIdle := config.MaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	lifetime := time.Duration(config.ConnMaxLifetimeSec) * time.Second
+	if lifetime == 0 {
+		lifetime = defaultConnMaxLifetime
+	}
+	idleTime := time.Duration(config.ConnMaxIdleTimeSec) * time.Second
+	if idleTime == 0 {
+		idleTime = defaultConnMaxIdleTime
+	}
+
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(lifetime)
+	db.SetConnMaxIdleTime(idleTime)
+}
+
+func poolFingerprint(connector *models.Connector) (string, error) {
+	data, err := json.Marshal(struct {
+		Type   string
+		Config models.DatabaseConfig
+		Pool   *models.PoolConfig
+	}{connector.Type, connector.Config, connector.Pool})
+	return string(data), err
+}
+
+// validatePoolConfig rejects negative pool settings
+func validatePoolConfig(config *models.PoolConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.MaxOpenConns < 0 || config.MaxIdleConns < 0 || config.ConnMaxLifetimeSec < 0 || config.ConnMaxIdleTimeSec < 0 {
+		return fmt.Errorf("pool settings must not be negative")
+	}
+	if config.MaxOpenConns > 0 && config.MaxIdleConns > config.MaxOpenConns {
+		return fmt.Errorf("pool maxIdleConns must not exceed maxOpenConns")
+	}
+	return nil
+}
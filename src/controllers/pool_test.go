@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"axis/src/dialect"
+	"axis/src/models"
+	"axis/src/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolRegistry_ReusesPoolPerConnector(t *testing.T) {
+	registry := &poolRegistry{pools: make(map[string]*connectorPool)}
+	d, _ := dialect.Lookup("sqlite")
+	connector := &models.Connector{
+		ID:     "pooled",
+		Type:   "sqlite",
+		Config: models.DatabaseConfig{Path: newSQLiteDatabase(t, "CREATE TABLE t (x INTEGER)")},
+		Pool:   &models.PoolConfig{MaxOpenConns: 3},
+	}
+
+	first, err := registry.get(connector, d)
+	assert.NoError(t, err)
+	second, err := registry.get(connector, d)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 3, first.Stats().MaxOpenConnections)
+
+	// Changed settings get a fresh pool and the old one is closed
+	connector.Pool = &models.PoolConfig{MaxOpenConns: 5}
+	third, err := registry.get(connector, d)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, third)
+	assert.Equal(t, 5, third.Stats().MaxOpenConnections)
+	assert.Error(t, first.Ping())
+
+	registry.invalidate(connector.ID)
+	assert.Error(t, third.Ping())
+	fourth, err := registry.get(connector, d)
+	assert.NoError(t, err)
+	assert.NoError(t, fourth.Ping())
+	registry.invalidate(connector.ID)
+}
+
+func TestConfigurePool_Defaults(t *testing.T) {
+	d, _ := dialect.Lookup("sqlite")
+	registry := &poolRegistry{pools: make(map[string]*connectorPool)}
+	db, err := registry.get(&models.Connector{ID: "defaults", Type: "sqlite"}, d)
+	assert.NoError(t, err)
+	defer registry.invalidate("defaults")
+
+	assert.Equal(t, defaultMaxOpenConns, db.Stats().MaxOpenConnections)
+}
+
+func TestValidatePoolConfig(t *testing.T) {
+	assert.NoError(t, validatePoolConfig(nil))
+	assert.NoError(t, validatePoolConfig(&models.PoolConfig{MaxOpenConns: 10, MaxIdleConns: 10}))
+	assert.Error(t, validatePoolConfig(&models.PoolConfig{MaxOpenConns: -1}))
+	assert.Error(t, validatePoolConfig(&models.PoolConfig{MaxOpenConns: 2, MaxIdleConns: 5}))
+}
+
+func TestUpdateConnector_InvalidatesPool(t *testing.T) {
+	allowTestDataDir(t)
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+
+	connector := &models.Connector{
+		ID:     "pooled-connector",
+		Type:   "sqlite",
+		Config: models.DatabaseConfig{Path: newSQLiteDatabase(t, "CREATE TABLE t (x INTEGER)")},
+	}
+	assert.NoError(t, store.SaveConnector(connector))
+
+	d, _ := dialect.Lookup("sqlite")
+	db, err := pools.get(connector, d)
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/connectors/:id", UpdateConnector)
+	router.DELETE("/connectors/:id", DeleteConnector)
+
+	body := `{"type": "sqlite", "config": {"path": "` + connector.Config.Path + `"}, "pool": {"maxOpenConns": 1}}`
+	req := httptest.NewRequest(http.MethodPut, "/connectors/pooled-connector", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Error(t, db.Ping(), "the old pool is closed")
+
+	updated, _ := store.LoadConnector(connector.ID)
+	db, err = pools.get(updated, d)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, db.Stats().MaxOpenConnections)
+
+	req = httptest.NewRequest(http.MethodDelete, "/connectors/pooled-connector", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Error(t, db.Ping())
+}
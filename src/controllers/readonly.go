@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlLexicon describes how one database tokenizes quotes and comments.
+// Contract queries are checked under every lexicon, so a query cannot hide a
+// second statement behind quoting that only some databases understand.
+type sqlLexicon struct {
+	backslashEscapes bool // backslashes escape quotes in all strings
+	escapeStrings    bool // E'...' strings use backslash escapes
+	dollarQuotes     bool // $tag$...$tag$ strings
+	nestedComments   bool // /* */ comments nest
+	hashComments     bool // # starts a line comment
+	dashNeedsSpace   bool // -- only starts a comment when followed by whitespace
+	backticks        bool // `name` identifiers
+	brackets         bool // [name] identifiers
+	hintComments     bool // /*! ... */ comments are executed
+}
+
+var sqlLexiconsByType = map[string]sqlLexicon{
+	"postgres":  {escapeStrings: true, dollarQuotes: true, nestedComments: true},
+	"mysql":     {backslashEscapes: true, hashComments: true, dashNeedsSpace: true, backticks: true, hintComments: true},
+	"sqlite":    {backticks: true, brackets: true},
+	"sqlserver": {nestedComments: true, brackets: true},
+}
+
+var sqlLexicons = []sqlLexicon{
+	sqlLexiconsByType["postgres"],
+	sqlLexiconsByType["mysql"],
+	sqlLexiconsByType["sqlite"],
+	sqlLexiconsByType["sqlserver"],
+}
+
+// writeKeywords may not appear anywhere in a contract query. Column names
+// that collide with them must be double-quoted.
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true, "INTO": true, "COPY": true, "LOCK": true,
+	"CALL": true, "EXEC": true, "EXECUTE": true, "COMMIT": true, "ROLLBACK": true,
+}
+
+// validateReadOnlyQuery accepts a single SELECT or WITH statement that does
+// not write. Contracts on file and rest connectors carry no SQL.
+func validateReadOnlyQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	for _, lexicon := range sqlLexicons {
+		words, _, err := scanStatement(query, lexicon)
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 || (words[0] != "SELECT" && words[0] != "WITH") {
+			return fmt.Errorf("query must be a single SELECT or WITH statement")
+		}
+		for _, word := range words {
+			if writeKeywords[word] {
+				return fmt.Errorf("query must not contain %s", word)
+			}
+		}
+	}
+	return nil
+}
+
+// trimStatementEnd cuts a query at the semicolon ending it, dropping any
+// comment after it, as read by the lexicon of the connector type. Queries
+// the lexer rejects are only trimmed of whitespace and semicolons.
+func trimStatementEnd(query, connectorType string) string {
+	if _, end, err := scanStatement(query, sqlLexiconsByType[connectorType]); err == nil {
+		query = query[:end]
+	}
+	return strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+}
+
+// scanStatement returns the upper-cased keywords of a query, skipping
+// literals, quoted identifiers, comments and :name parameters, and the
+// offset where the statement ends. Only a trailing semicolon may end the
+// statement.
+func scanStatement(query string, lex sqlLexicon) ([]string, int, error) {
+	var words []string
+	ended := false
+	statementEnd := len(query)
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		// Whitespace and comments may follow the statement
+		switch {
+		case c <= ' ':
+			i++
+			continue
+		case strings.HasPrefix(query[i:], "--") && (!lex.dashNeedsSpace || i+2 == len(query) || query[i+2] <= ' '),
+			c == '#' && lex.hashComments:
+			i = skipLine(query, i)
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			if lex.hintComments && strings.HasPrefix(query[i:], "/*!") {
+				return nil, 0, fmt.Errorf("query must not contain executable comments")
+			}
+			end, err := skipBlockComment(query, i, lex.nestedComments)
+			if err != nil {
+				return nil, 0, err
+			}
+			i = end
+			continue
+		}
+
+		if ended {
+			return nil, 0, fmt.Errorf("query must be a single SELECT or WITH statement")
+		}
+
+		end := i
+		switch {
+		case c == ';':
+			ended = true
+			statementEnd = i
+		case c == '\'':
+			end = skipQuoted(query, i, '\'', lex.backslashEscapes)
+		case c == '"':
+			end = skipQuoted(query, i, '"', lex.backslashEscapes)
+		case c == '`' && lex.backticks:
+			end = skipQuoted(query, i, '`', false)
+		case c == '[' && lex.brackets:
+			end = skipQuoted(query, i, ']', false)
+		case c == '$' && lex.dollarQuotes && (i == 0 || !isIdentifierChar(query[i-1], false)):
+			end = skipDollarQuoted(query, i)
+		case isIdentifierChar(c, true):
+			for end+1 < len(query) && isIdentifierChar(query[end+1], false) {
+				end++
+			}
+			word := strings.ToUpper(query[i : end+1])
+			if word == "E" && lex.escapeStrings && end+1 < len(query) && query[end+1] == '\'' {
+				end = skipQuoted(query, end+1, '\'', true)
+				break
+			}
+			// Parameters and qualified column names are never statements
+			if i > 0 && (query[i-1] == ':' || query[i-1] == '.' || query[i-1] == '@') {
+				break
+			}
+			words = append(words, word)
+		}
+		if end >= len(query) {
+			return nil, 0, fmt.Errorf("query has an unterminated quote or comment")
+		}
+		i = end + 1
+	}
+	return words, statementEnd, nil
+}
+
+func skipBlockComment(query string, i int, nested bool) (int, error) {
+	depth := 0
+	for j := i; j < len(query); {
+		switch {
+		case strings.HasPrefix(query[j:], "/*") && (nested || depth == 0):
+			depth++
+			j += 2
+		case strings.HasPrefix(query[j:], "*/"):
+			depth--
+			j += 2
+			if depth == 0 {
+				return j, nil
+			}
+		default:
+			j++
+		}
+	}
+	return 0, fmt.Errorf("query has an unterminated quote or comment")
+}
+
+func skipLine(query string, i int) int {
+	if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+		return i + end + 1
+	}
+	return len(query)
+}
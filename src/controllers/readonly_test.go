@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"axis/src/dialect"
+	"axis/src/models"
+	"axis/src/storage"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReadOnlyQuery_Accepts(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"SELECT 1",
+		"select name from city where country_code = :country_code;",
+		"WITH big AS (SELECT * FROM city WHERE population > 100000) SELECT name FROM big",
+		"SELECT 'DROP TABLE city; --' AS note, \"update\" FROM city",
+		"SELECT c.update, t.delete FROM city c -- DELETE is fine in a comment",
+		"SELECT 1 /* outer /* nested */ still a comment */",
+		"SELECT 'it''s' AS quoted",
+		"SELECT $1 AS first",
+	} {
+		assert.NoError(t, validateReadOnlyQuery(query), query)
+	}
+}
+
+func TestValidateReadOnlyQuery_Rejects(t *testing.T) {
+	for query, message := range map[string]string{
+		"DELETE FROM city": "query must be a single SELECT or WITH statement",
+		"DROP TABLE city":  "query must be a single SELECT or WITH statement",
+		"  -- comment\n  UPDATE city SET name = 'x'": "query must be a single SELECT or WITH statement",
+		"SELECT 1; DELETE FROM city":                 "query must be a single SELECT or WITH statement",
+		"SELECT 1;;":                                 "query must be a single SELECT or WITH statement",
+		"WITH gone AS (DELETE FROM city RETURNING *) SELECT * FROM gone": "query must not contain DELETE",
+		"SELECT * INTO backup FROM city":                                 "query must not contain INTO",
+		"SELECT * FROM city FOR UPDATE":                                  "query must not contain UPDATE",
+		"SELECT 'unterminated":                                           "query has an unterminated quote or comment",
+		"SELECT 1 /* open":                                               "query has an unterminated quote or comment",
+		"SELECT 1 /*! INTO OUTFILE '/tmp/x' */":                          "query must not contain executable comments",
+	} {
+		err := validateReadOnlyQuery(query)
+		if assert.Error(t, err, query) {
+			assert.Equal(t, message, err.Error(), query)
+		}
+	}
+
+	// Quoting only some databases understand cannot hide a second statement
+	for _, query := range []string{
+		"SELECT 'a\\'', 'b'; DROP TABLE city -- '",
+		"SELECT $$'$$; DROP TABLE city; --'",
+		"SELECT 1 # '\n; DROP TABLE city",
+		"SELECT [a']; DROP TABLE city; --']",
+		"SELECT 1 /* /* */ ; DROP TABLE city; -- */",
+		"SELECT E'\\'', '\\'; DROP TABLE city; --'",
+	} {
+		assert.Error(t, validateReadOnlyQuery(query), query)
+	}
+}
+
+func TestCreateContract_RejectsWritingQuery(t *testing.T) {
+	defer SetContractStore(contractStore)
+	SetContractStore(storage.NewMemoryStore())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contracts", CreateContract)
+
+	req := httptest.NewRequest(http.MethodPost, "/contracts", strings.NewReader(`{"name": "purge", "query": {"sqlQuery": "DELETE FROM city"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"error": "query must be a single SELECT or WITH statement"}`, rr.Body.String())
+}
+
+func TestOpenSQLSource_ReadOnlyTransaction(t *testing.T) {
+	connector := &models.Connector{
+		ID:   "read-only",
+		Type: "sqlite",
+		Config: models.DatabaseConfig{Path: newSQLiteDatabase(t,
+			"CREATE TABLE city (name TEXT)",
+			"INSERT INTO city VALUES ('Oslo')",
+		)},
+	}
+	defer pools.invalidate(connector.ID)
+
+	// A contract stored before validation is refused at execution
+	_, err := openSQLSource(context.Background(), connector, models.DatabaseQuery{SQLQuery: "DELETE FROM city"}, nil)
+	assert.Equal(t, http.StatusBadRequest, err.(*sourceError).status)
+
+	source, err := openSQLSource(context.Background(), connector, models.DatabaseQuery{SQLQuery: "SELECT name FROM city"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"name": "Oslo"}}, readSource(t, source))
+	assert.NoError(t, source.close())
+
+	// The transaction is rolled back and the connection returned to the pool
+	d, _ := dialect.Lookup("sqlite")
+	db, err := pools.get(connector, d)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, db.Stats().InUse)
+	assert.Equal(t, 1, db.Stats().Idle)
+
+	source, err = openSQLSource(context.Background(), connector, models.DatabaseQuery{SQLQuery: "SELECT COUNT(*) AS n FROM city"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), readSource(t, source)[0]["n"])
+	assert.NoError(t, source.close())
+}
@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// restConnectorType reads records from an upstream HTTP service
+const restConnectorType = "rest"
+
+// restClient performs upstream calls; tests may replace it. It sets no
+// timeout of its own, so calls end with the contract's deadline and report
+// a timeout rather than an upstream failure.
+var restClient = &http.Client{}
+
+// pathParamPattern finds :name references in a request path
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// openRESTSource calls the upstream service and serves the records it returns.
+// Filters and pagination with a declared query parameter are left to the
+// upstream; everything else is applied to the records in memory.
+func openRESTSource(ctx context.Context, connector *models.Connector, query models.DatabaseQuery, params map[string]any) (rowSource, error) {
+	spec := query.Request
+	if spec == nil {
+		return nil, &sourceError{http.StatusBadRequest, "Contract has no request for a rest connector"}
+	}
+
+	config, err := resolveSecrets(connector.Config)
+	if err != nil {
+		logConnectorError(connector, "resolving secrets", err)
+		return nil, &sourceError{http.StatusInternalServerError, "Failed to resolve connector secrets"}
+	}
+	req, err := buildRESTRequest(config, *spec, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := restClient.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &sourceError{http.StatusBadGateway, "Upstream request failed"}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &sourceError{http.StatusBadGateway, fmt.Sprintf("Upstream returned status %d", resp.StatusCode)}
+	}
+
+	var doc any
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &sourceError{http.StatusBadGateway, "Upstream returned invalid JSON"}
+	}
+
+	records, err := selectJSONPath(doc, spec.RecordsPath)
+	if err != nil {
+		return nil, &sourceError{http.StatusBadGateway, err.Error()}
+	}
+
+	var local []models.FilterCondition
+	for _, filter := range query.Filters {
+		if !sentUpstream(*spec, filter) {
+			local = append(local, filter)
+		}
+	}
+	match, err := compileFilters(local)
+	if err != nil {
+		return nil, &sourceError{http.StatusBadRequest, err.Error()}
+	}
+
+	var rows []map[string]any
+	switch v := records.(type) {
+	case nil:
+	case map[string]any:
+		if match(v) {
+			rows = append(rows, v)
+		}
+	case []any:
+		for _, record := range v {
+			row, ok := record.(map[string]any)
+			if !ok {
+				return nil, &sourceError{http.StatusBadGateway, "Upstream records must be JSON objects"}
+			}
+			if match(row) {
+				rows = append(rows, row)
+			}
+		}
+	default:
+		return nil, &sourceError{http.StatusBadGateway, "Upstream records must be JSON objects"}
+	}
+
+	sortRows(rows, query.Sort)
+	if spec.PageParam == "" {
+		rows = paginateRows(rows, query.Pagination)
+	}
+	return newMemorySource(rows), nil
+}
+
+// buildRESTRequest assembles the upstream request for a contract execution
+func buildRESTRequest(config models.DatabaseConfig, spec models.RESTRequest, query models.DatabaseQuery, params map[string]any) (*http.Request, error) {
+	path := pathParamPattern.ReplaceAllStringFunc(spec.Path, func(ref string) string {
+		if value, ok := params[ref[1:]]; ok {
+			return url.PathEscape(formatCell(value))
+		}
+		return ref
+	})
+
+	u, err := url.Parse(strings.TrimRight(config.BaseURL, "/") + "/" + strings.TrimLeft(path, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &sourceError{http.StatusInternalServerError, "Invalid upstream URL"}
+	}
+
+	values := u.Query()
+	for _, filter := range query.Filters {
+		if !sentUpstream(spec, filter) {
+			continue
+		}
+		param := spec.FilterParams[filter.Field]
+		if options, ok := filter.Value.([]any); ok {
+			for _, option := range options {
+				values.Add(param, formatCell(option))
+			}
+		} else {
+			values.Add(param, formatCell(filter.Value))
+		}
+	}
+	if p := query.Pagination; p != nil && spec.PageParam != "" {
+		values.Set(spec.PageParam, strconv.Itoa(p.Page))
+		if spec.PageSizeParam != "" {
+			values.Set(spec.PageSizeParam, strconv.Itoa(p.PageSize))
+		}
+	}
+	u.RawQuery = values.Encode()
+
+	method := strings.ToUpper(spec.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if spec.Body != nil {
+		data, err := json.Marshal(spec.Body)
+		if err != nil {
+			return nil, &sourceError{http.StatusInternalServerError, "Invalid upstream request body"}
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, &sourceError{http.StatusInternalServerError, "Invalid upstream request"}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	authorize(req, config)
+	return req, nil
+}
+
+// authorize adds the connector's headers and credentials to a request
+func authorize(req *http.Request, config models.DatabaseConfig) {
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	if config.Auth == nil {
+		return
+	}
+	switch config.Auth.Type {
+	case "basic":
+		req.SetBasicAuth(config.Auth.Username, config.Auth.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+config.Auth.Token)
+	}
+}
+
+// sentUpstream reports whether a filter is carried by a query parameter
+func sentUpstream(spec models.RESTRequest, filter models.FilterCondition) bool {
+	if _, ok := spec.FilterParams[filter.Field]; !ok {
+		return false
+	}
+	return filter.Operator == models.OperatorEquals || filter.Operator == models.OperatorIn
+}
+
+// upstreamStatusError is an upstream answer showing the connector does not work
+type upstreamStatusError struct {
+	status int
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("upstream returned status %d", e.status)
+}
+
+// pingRESTConnector checks that the upstream answers and accepts the credentials
+func pingRESTConnector(ctx context.Context, config models.DatabaseConfig) error {
+	config, err := resolveSecrets(config)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.BaseURL, nil)
+	if err != nil {
+		return err
+	}
+	authorize(req, config)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode >= 500 {
+		return &upstreamStatusError{resp.StatusCode}
+	}
+	return nil
+}
+
+// selectJSONPath evaluates the subset of JSONPath needed to locate records:
+// $, .name, ['name'] and [n], as in $.data.items or $['results'][0].rows
+func selectJSONPath(doc any, path string) (any, error) {
+	path = strings.TrimSpace(path)
+	if path == "" || path == "$" {
+		return doc, nil
+	}
+	if path[0] != '$' {
+		return nil, fmt.Errorf("records path %q must start with $", path)
+	}
+
+	current := doc
+	for i := 1; i < len(path); {
+		var key string
+		index := -1
+
+		switch {
+		case path[i] == '.':
+			end := i + 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			key = path[i+1 : end]
+			i = end
+		case path[i] == '[' && i+1 < len(path) && (path[i+1] == '\'' || path[i+1] == '"'):
+			quote := path[i+1]
+			end := strings.IndexByte(path[i+2:], quote)
+			if end < 0 || i+2+end+1 >= len(path) || path[i+2+end+1] != ']' {
+				return nil, fmt.Errorf("records path %q is malformed", path)
+			}
+			key = path[i+2 : i+2+end]
+			i += end + 4
+		case path[i] == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("records path %q is malformed", path)
+			}
+			n, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("records path %q is malformed", path)
+			}
+			index = n
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("records path %q is malformed", path)
+		}
+
+		if index >= 0 {
+			arr, ok := current.([]any)
+			if !ok || index >= len(arr) {
+				return nil, fmt.Errorf("records path %q not found in upstream response", path)
+			}
+			current = arr[index]
+			continue
+		}
+
+		obj, ok := current.(map[string]any)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("records path %q not found in upstream response", path)
+		}
+		if current, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("records path %q not found in upstream response", path)
+		}
+	}
+	return current, nil
+}
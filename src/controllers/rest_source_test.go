@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"axis/src/storage"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCityService stands in for an upstream service that lists cities per country
+func newCityService(t *testing.T) (*httptest.Server, *http.Request) {
+	var last http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = *r.Clone(r.Context())
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v1/countries/NOR/cities" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"items": [
+			{"name": "Oslo", "population": 508726, "district": "Oslo"},
+			{"name": "Bergen", "population": 230948, "district": "Hordaland"},
+			{"name": "Trondheim", "population": 150166, "district": "Trondelag"}
+		]}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &last
+}
+
+func newRESTConnector(baseURL string) *models.Connector {
+	return &models.Connector{
+		ID:   "cities-api",
+		Type: restConnectorType,
+		Config: models.DatabaseConfig{
+			BaseURL: baseURL + "/v1",
+			Headers: map[string]string{"X-Client": "axis"},
+			Auth:    &models.AuthConfig{Type: "bearer", Token: "s3cret"},
+		},
+	}
+}
+
+var cityRequest = &models.RESTRequest{
+	Path:          "/countries/:country_code/cities",
+	RecordsPath:   "$.data.items",
+	FilterParams:  map[string]string{"district": "district"},
+	PageParam:     "page",
+	PageSizeParam: "per_page",
+}
+
+func TestRESTSource(t *testing.T) {
+	server, last := newCityService(t)
+
+	source, err := openRESTSource(context.Background(), newRESTConnector(server.URL), models.DatabaseQuery{
+		Request: cityRequest,
+		Filters: []models.FilterCondition{
+			{Field: "district", Operator: models.OperatorIn, Value: []any{"Oslo", "Hordaland"}},
+			{Field: "population", Operator: models.OperatorGreater, Value: float64(200000)},
+		},
+		Sort:       []models.SortOption{{Field: "population", Direction: "asc"}},
+		Pagination: &models.PaginationOptions{Page: 1, PageSize: 50},
+	}, map[string]any{"country_code": "NOR"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "axis", last.Header.Get("X-Client"))
+	assert.Equal(t, []string{"Oslo", "Hordaland"}, last.URL.Query()["district"])
+	assert.Equal(t, "1", last.URL.Query().Get("page"))
+	assert.Equal(t, "50", last.URL.Query().Get("per_page"))
+	assert.Empty(t, last.URL.Query().Get("population"))
+
+	// The population filter is not declared upstream and was applied locally
+	rows := readSource(t, source)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "Bergen", rows[0]["name"])
+	assert.Equal(t, json.Number("508726"), rows[1]["population"])
+}
+
+func TestRESTSource_UpstreamErrors(t *testing.T) {
+	server, _ := newCityService(t)
+
+	connector := newRESTConnector(server.URL)
+	connector.Config.Auth.Token = "wrong"
+	_, err := openRESTSource(context.Background(), connector, models.DatabaseQuery{Request: cityRequest}, map[string]any{"country_code": "NOR"})
+	assert.Equal(t, http.StatusBadGateway, err.(*sourceError).status)
+	assert.Equal(t, "Upstream returned status 401", err.Error())
+
+	request := *cityRequest
+	request.RecordsPath = "$.data.rows"
+	_, err = openRESTSource(context.Background(), newRESTConnector(server.URL), models.DatabaseQuery{Request: &request}, map[string]any{"country_code": "NOR"})
+	assert.Equal(t, http.StatusBadGateway, err.(*sourceError).status)
+
+	_, err = openRESTSource(context.Background(), newRESTConnector(server.URL), models.DatabaseQuery{}, nil)
+	assert.Equal(t, http.StatusBadRequest, err.(*sourceError).status)
+}
+
+func TestSelectJSONPath(t *testing.T) {
+	var doc any
+	assert.NoError(t, json.Unmarshal([]byte(`{"results": [{"rows": [1, 2]}], "odd key": {"x": true}}`), &doc))
+
+	for path, expected := range map[string]any{
+		"":                        doc,
+		"$":                       doc,
+		"$.results[0].rows":       []any{float64(1), float64(2)},
+		"$['results'][0]['rows']": []any{float64(1), float64(2)},
+		`$["odd key"].x`:          true,
+		"$.results[0].rows[1]":    float64(2),
+	} {
+		value, err := selectJSONPath(doc, path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, expected, value, path)
+	}
+
+	for _, path := range []string{"data", "$.missing", "$.results[3]", "$.results[x]", "$['results'", "$..results"} {
+		_, err := selectJSONPath(doc, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestExecuteContract_RESTConnector(t *testing.T) {
+	defer SetContractStore(contractStore)
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetContractStore(store)
+	SetConnectorStore(store)
+
+	server, _ := newCityService(t)
+	connector := newRESTConnector(server.URL)
+	assert.NoError(t, store.SaveConnector(connector))
+	assert.NoError(t, store.SaveContract(&models.Contract{
+		ID: "rest-contract",
+		Parameters: []models.ParameterDefinition{
+			{Name: "country_code", Type: models.FieldTypeString, Required: true},
+		},
+		Query: models.DatabaseQuery{
+			ConnectorID: connector.ID,
+			Request:     cityRequest,
+			Fields: []models.FieldDefinition{
+				{Name: "name", Type: models.FieldTypeString, Sortable: true},
+			},
+		},
+		ResponseTemplate: models.ResponseTemplate{
+			Mode:          models.TemplateModeTyped,
+			Template:      map[string]any{"city": "{{.name}}", "district": "{{.district}}"},
+			Anonymization: []models.AnonymizationRule{{Field: "district", Method: "mask"}},
+		},
+	}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contracts/:id/execute", ExecuteContract)
+
+	body := `{"parameters": {"country_code": "NOR"}, "sort": [{"field": "name", "direction": "desc"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/contracts/rest-contract/execute", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Results []map[string]any `json:"results"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []map[string]any{
+		{"city": "Trondheim", "district": "*********"},
+		{"city": "Oslo", "district": "****"},
+		{"city": "Bergen", "district": "*********"},
+	}, response.Results)
+}
+
+func TestExecuteContract_TimeoutReturns504(t *testing.T) {
+	defer SetContractStore(contractStore)
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetContractStore(store)
+	SetConnectorStore(store)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, store.SaveConnector(&models.Connector{
+		ID: "slow-api", Type: restConnectorType, Config: models.DatabaseConfig{BaseURL: server.URL}, TimeoutSec: 10,
+	}))
+	assert.NoError(t, store.SaveContract(&models.Contract{
+		ID: "slow-contract",
+		Query: models.DatabaseQuery{
+			ConnectorID: "slow-api",
+			Request:     &models.RESTRequest{Path: "/report"},
+			TimeoutSec:  1,
+		},
+	}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contracts/:id/execute", ExecuteContract)
+
+	req := httptest.NewRequest(http.MethodPost, "/contracts/slow-contract/execute", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	started := time.Now()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	assert.JSONEq(t, `{"error": "Query timed out"}`, rr.Body.String())
+	assert.Less(t, time.Since(started), 3*time.Second)
+
+	// The client sets no limit of its own that could cut a longer deadline short
+	assert.Zero(t, restClient.Timeout)
+}
@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"axis/src/keyring"
+	"axis/src/models"
+	"axis/src/storage"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Secret references let connector files name a credential instead of
+// holding it: env:PG_PASSWORD reads an environment variable and
+// file:/run/secrets/pg reads a file. Any other value is the secret itself.
+const (
+	envSecretPrefix  = "env:"
+	fileSecretPrefix = "file:"
+)
+
+// SecretPolicy names the references connectors may use. Anyone who can
+// create a connector can have its credentials sent to a host of their
+// choosing, so only variables and files set aside for connectors may be
+// referenced. The zero policy allows no references.
+type SecretPolicy struct {
+	// EnvPrefixes lists the prefixes of the environment variables that may
+	// be referenced
+	EnvPrefixes []string
+	// Dir is the directory whose files may be referenced, by absolute path
+	// or relative to it
+	Dir string
+}
+
+var secretPolicy SecretPolicy
+
+// SetSecretPolicy injects the policy restricting secret references
+func SetSecretPolicy(p SecretPolicy) {
+	secretPolicy = p
+}
+
+// redactedSecret replaces plaintext secrets in API responses
+const redactedSecret = "********"
+
+func isSecretReference(value string) bool {
+	return strings.HasPrefix(value, envSecretPrefix) || strings.HasPrefix(value, fileSecretPrefix)
+}
+
+// connectorKeyring encrypts credentials before connectors are stored. Without
+// one they are stored as given.
+var connectorKeyring *keyring.Keyring
+
+// SetKeyring injects the keyring that encrypts stored connector credentials
+func SetKeyring(k *keyring.Keyring) {
+	connectorKeyring = k
+}
+
+// resolveSecret returns the credential a connector setting refers to or
+// holds encrypted. Errors name the reference but never the secret.
+func resolveSecret(value string) (string, error) {
+	switch {
+	case keyring.IsEncrypted(value):
+		if connectorKeyring == nil {
+			return "", fmt.Errorf("connector credentials are encrypted but no encryption key is configured")
+		}
+		return connectorKeyring.Decrypt(value)
+	case strings.HasPrefix(value, envSecretPrefix):
+		name := strings.TrimPrefix(value, envSecretPrefix)
+		if !allowedSecretVariable(name) {
+			return "", fmt.Errorf("secret environment variable %s is not allowed", name)
+		}
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, fileSecretPrefix):
+		path, err := secretFilePath(strings.TrimPrefix(value, fileSecretPrefix))
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("secret file %s is not readable", path)
+		}
+		// Secret files usually end with a newline that is not part of the secret
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return value, nil
+	}
+}
+
+// allowedSecretVariable reports whether the policy lets connectors read an
+// environment variable
+func allowedSecretVariable(name string) bool {
+	for _, prefix := range secretPolicy.EnvPrefixes {
+		if prefix != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretFilePath returns the file a reference names, provided it lies in
+// the policy's directory
+func secretFilePath(ref string) (string, error) {
+	for _, part := range strings.Split(filepath.ToSlash(ref), "/") {
+		if part == ".." {
+			return "", fmt.Errorf("secret file %s is not allowed", ref)
+		}
+	}
+	if secretPolicy.Dir == "" || ref == "" {
+		return "", fmt.Errorf("secret file %s is not allowed", ref)
+	}
+
+	dir := filepath.Clean(secretPolicy.Dir)
+	path := filepath.Clean(ref)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("secret file %s is not allowed", ref)
+	}
+	return path, nil
+}
+
+// validateSecretReferences rejects references the policy does not allow,
+// before they are stored
+func validateSecretReferences(config models.DatabaseConfig) error {
+	_, err := mapSecrets(config, func(value string) (string, error) {
+		switch {
+		case strings.HasPrefix(value, envSecretPrefix):
+			if name := strings.TrimPrefix(value, envSecretPrefix); !allowedSecretVariable(name) {
+				return "", fmt.Errorf("secret environment variable %s is not allowed", name)
+			}
+		case strings.HasPrefix(value, fileSecretPrefix):
+			if _, err := secretFilePath(strings.TrimPrefix(value, fileSecretPrefix)); err != nil {
+				return "", err
+			}
+		}
+		return value, nil
+	})
+	return err
+}
+
+// rejectSecretReferences refuses any reference, for connection settings
+// that are tried without being saved
+func rejectSecretReferences(config models.DatabaseConfig) error {
+	_, err := mapSecrets(config, func(value string) (string, error) {
+		if isSecretReference(value) {
+			return "", fmt.Errorf("secret references are only resolved for saved connectors")
+		}
+		return value, nil
+	})
+	return err
+}
+
+// mapSecrets returns a copy of config with fn applied to each credential.
+// Header values count as credentials, since REST APIs commonly take their
+// key in a header.
+func mapSecrets(config models.DatabaseConfig, fn func(string) (string, error)) (models.DatabaseConfig, error) {
+	var err error
+	if config.Password, err = fn(config.Password); err != nil {
+		return config, err
+	}
+	if config.Headers != nil {
+		headers := make(map[string]string, len(config.Headers))
+		for name, value := range config.Headers {
+			if headers[name], err = fn(value); err != nil {
+				return config, err
+			}
+		}
+		config.Headers = headers
+	}
+	if config.Auth != nil {
+		auth := *config.Auth
+		if auth.Password, err = fn(auth.Password); err != nil {
+			return config, err
+		}
+		if auth.Token, err = fn(auth.Token); err != nil {
+			return config, err
+		}
+		config.Auth = &auth
+	}
+	return config, nil
+}
+
+// resolveSecrets returns a copy of config with its credentials resolved, for
+// use only while connecting
+func resolveSecrets(config models.DatabaseConfig) (models.DatabaseConfig, error) {
+	return mapSecrets(config, resolveSecret)
+}
+
+// sealSecrets encrypts the plaintext credentials of a connector about to be
+// stored. References and values already encrypted are kept.
+func sealSecrets(connector models.Connector) (models.Connector, error) {
+	if connectorKeyring == nil {
+		return connector, nil
+	}
+	var err error
+	connector.Config, err = mapSecrets(connector.Config, func(value string) (string, error) {
+		if value == "" || isSecretReference(value) || keyring.IsEncrypted(value) {
+			return value, nil
+		}
+		return connectorKeyring.Encrypt(value)
+	})
+	return connector, err
+}
+
+// RotateConnectorKeys re-encrypts the credentials of every stored connector
+// with the current key, including plaintext ones stored before a key was
+// configured. The keyring must still hold the previous key.
+func RotateConnectorKeys(store storage.ConnectorStore) (int, error) {
+	if connectorKeyring == nil {
+		return 0, fmt.Errorf("no encryption key is configured")
+	}
+	connectors, err := store.ListConnectors()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, connector := range connectors {
+		connector.Config, err = mapSecrets(connector.Config, func(value string) (string, error) {
+			if keyring.IsEncrypted(value) {
+				return connectorKeyring.Decrypt(value)
+			}
+			return value, nil
+		})
+		if err != nil {
+			return i, fmt.Errorf("connector %s: %w", connector.ID, err)
+		}
+		if connector, err = sealSecrets(connector); err != nil {
+			return i, fmt.Errorf("connector %s: %w", connector.ID, err)
+		}
+		if err := store.SaveConnector(&connector); err != nil {
+			return i, fmt.Errorf("connector %s: %w", connector.ID, err)
+		}
+	}
+	return len(connectors), nil
+}
+
+// newConnectorView masks the credentials of a connector for clients.
+// References stay visible since they name, not hold, a secret.
+func newConnectorView(connector models.Connector) models.ConnectorView {
+	connector.Config, _ = mapSecrets(connector.Config, func(value string) (string, error) {
+		if value == "" || isSecretReference(value) {
+			return value, nil
+		}
+		return redactedSecret, nil
+	})
+	return models.ConnectorView{Connector: connector}
+}
+
+// keepStoredSecrets fills in the credentials an update omitted or sent back
+// masked with the stored ones. They are only carried over while the
+// connector still points at the same place, so changing the host cannot be
+// used to send a stored password somewhere else.
+func keepStoredSecrets(update *models.Connector, stored *models.Connector) error {
+	sameTarget := update.Type == stored.Type &&
+		update.Config.Host == stored.Config.Host &&
+		update.Config.Port == stored.Config.Port &&
+		update.Config.User == stored.Config.User &&
+		update.Config.DBName == stored.Config.DBName &&
+		update.Config.Path == stored.Config.Path &&
+		update.Config.BaseURL == stored.Config.BaseURL
+
+	keep := func(value, storedValue string) (string, error) {
+		if value != "" && value != redactedSecret {
+			return value, nil
+		}
+		if storedValue == "" {
+			return "", nil
+		}
+		if !sameTarget {
+			return "", fmt.Errorf("credentials must be sent again when the connection target changes")
+		}
+		return storedValue, nil
+	}
+
+	var err error
+	if update.Config.Password, err = keep(update.Config.Password, stored.Config.Password); err != nil {
+		return err
+	}
+	// Header values are masked like passwords, so omitted headers are kept too
+	if update.Config.Headers == nil && len(stored.Config.Headers) > 0 {
+		update.Config.Headers = make(map[string]string, len(stored.Config.Headers))
+		for name := range stored.Config.Headers {
+			update.Config.Headers[name] = ""
+		}
+	}
+	for name, value := range update.Config.Headers {
+		if update.Config.Headers[name], err = keep(value, stored.Config.Headers[name]); err != nil {
+			return err
+		}
+	}
+	// An omitted auth object keeps the stored one, like an omitted password
+	if update.Config.Auth == nil && stored.Config.Auth != nil {
+		auth := models.AuthConfig{Type: stored.Config.Auth.Type, Username: stored.Config.Auth.Username}
+		update.Config.Auth = &auth
+	}
+	if update.Config.Auth != nil && stored.Config.Auth != nil {
+		if update.Config.Auth.Password, err = keep(update.Config.Auth.Password, stored.Config.Auth.Password); err != nil {
+			return err
+		}
+		if update.Config.Auth.Token, err = keep(update.Config.Auth.Token, stored.Config.Auth.Token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
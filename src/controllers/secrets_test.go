@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"axis/src/keyring"
+	"axis/src/models"
+	"axis/src/storage"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// allowTestSecrets lets connectors reference AXIS_TEST_ variables and the
+// files of the temporary directory it returns
+func allowTestSecrets(t *testing.T) string {
+	dir := t.TempDir()
+	SetSecretPolicy(SecretPolicy{EnvPrefixes: []string{"AXIS_TEST_"}, Dir: dir})
+	t.Cleanup(func() { SetSecretPolicy(SecretPolicy{}) })
+	return dir
+}
+
+func TestResolveSecret(t *testing.T) {
+	dir := allowTestSecrets(t)
+	t.Setenv("AXIS_TEST_PG_PASSWORD", "from-env")
+	path := filepath.Join(dir, "pg")
+	assert.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+
+	for value, expected := range map[string]string{
+		"plain":                     "plain",
+		"":                          "",
+		"env:AXIS_TEST_PG_PASSWORD": "from-env",
+		"file:" + path:              "from-file",
+		"file:pg":                   "from-file",
+	} {
+		secret, err := resolveSecret(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, secret, value)
+	}
+
+	_, err := resolveSecret("env:AXIS_TEST_UNSET")
+	assert.EqualError(t, err, "secret environment variable AXIS_TEST_UNSET is not set")
+	_, err = resolveSecret("file:" + filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestSecretPolicy_RejectsReferencesOutsideIt(t *testing.T) {
+	dir := allowTestSecrets(t)
+	t.Setenv("ENCRYPTION_KEY", "do-not-leak")
+	outside := filepath.Join(t.TempDir(), "pg")
+	assert.NoError(t, os.WriteFile(outside, []byte("do-not-leak"), 0o600))
+
+	for _, ref := range []string{
+		"env:ENCRYPTION_KEY",
+		"env:PATH",
+		"file:" + outside,
+		"file:/proc/self/environ",
+		"file:../pg",
+		"file:" + dir + "/../pg",
+		"file:" + dir,
+		"file:",
+	} {
+		_, err := resolveSecret(ref)
+		assert.ErrorContains(t, err, "is not allowed", ref)
+		assert.Error(t, validateSecretReferences(models.DatabaseConfig{Password: ref}), ref)
+		assert.Error(t, validateSecretReferences(models.DatabaseConfig{Auth: &models.AuthConfig{Token: ref}}), ref)
+	}
+
+	// Without a policy no reference is allowed
+	SetSecretPolicy(SecretPolicy{})
+	assert.Error(t, validateSecretReferences(models.DatabaseConfig{Password: "env:AXIS_TEST_PG_PASSWORD"}))
+	assert.Error(t, validateSecretReferences(models.DatabaseConfig{Password: "file:pg"}))
+	assert.NoError(t, validateSecretReferences(models.DatabaseConfig{Password: "plain"}))
+}
+
+func TestSaveConnector_RejectsDisallowedReferences(t *testing.T) {
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+	allowTestSecrets(t)
+	assert.NoError(t, store.SaveConnector(&models.Connector{ID: "api", Type: restConnectorType}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/connectors", CreateConnector)
+	router.PUT("/connectors/:id", UpdateConnector)
+
+	body := `{"type": "rest", "config": {"baseUrl": "https://attacker.example", "auth": {"type": "bearer", "token": "env:ENCRYPTION_KEY"}}}`
+	for method, path := range map[string]string{http.MethodPost: "/connectors", http.MethodPut: "/connectors/api"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, method)
+		assert.JSONEq(t, `{"error": "secret environment variable ENCRYPTION_KEY is not allowed"}`, rr.Body.String(), method)
+	}
+
+	connectors, err := store.ListConnectors()
+	assert.NoError(t, err)
+	assert.Len(t, connectors, 1)
+	assert.Nil(t, connectors[0].Config.Auth)
+}
+
+func TestBuildConnectionString_ResolvesSecretReferences(t *testing.T) {
+	allowTestSecrets(t)
+	t.Setenv("AXIS_TEST_PG_PASSWORD", "s3cret")
+	config := models.DatabaseConfig{Host: "db", Port: 5432, User: "axis", Password: "env:AXIS_TEST_PG_PASSWORD", DBName: "world"}
+
+	dsn, err := buildConnectionString(config, "postgres")
+	assert.NoError(t, err)
+	assert.Equal(t, "host=db port=5432 user=axis password=s3cret dbname=world sslmode=disable connect_timeout=10", dsn)
+	assert.Equal(t, "env:AXIS_TEST_PG_PASSWORD", config.Password, "the connector keeps the reference")
+
+	config.Password = "env:AXIS_TEST_UNSET"
+	_, err = buildConnectionString(config, "postgres")
+	assert.Error(t, err)
+}
+
+func TestRESTSource_ResolvesSecretReferences(t *testing.T) {
+	allowTestSecrets(t)
+	t.Setenv("AXIS_TEST_API_TOKEN", "s3cret")
+	server, _ := newCityService(t)
+	connector := newRESTConnector(server.URL)
+	connector.Config.Auth.Token = "env:AXIS_TEST_API_TOKEN"
+
+	source, err := openRESTSource(context.Background(), connector, models.DatabaseQuery{Request: cityRequest}, map[string]any{"country_code": "NOR"})
+	assert.NoError(t, err)
+	assert.Len(t, readSource(t, source), 3)
+}
+
+func TestConnectorResponses_RedactSecrets(t *testing.T) {
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+
+	assert.NoError(t, store.SaveConnector(&models.Connector{
+		ID: "plain", Type: "postgres", Config: models.DatabaseConfig{User: "axis", Password: "hunter2"},
+	}))
+	assert.NoError(t, store.SaveConnector(&models.Connector{
+		ID: "referenced", Type: "postgres", Config: models.DatabaseConfig{User: "axis", Password: "env:PG_PASSWORD"},
+	}))
+	assert.NoError(t, store.SaveConnector(&models.Connector{
+		ID: "api", Type: restConnectorType, Config: models.DatabaseConfig{Auth: &models.AuthConfig{Type: "bearer", Token: "tok3n"}},
+	}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/connectors", ListConnectors)
+	router.GET("/connectors/:id", GetConnector)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/connectors/plain", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "hunter2")
+	var connector models.Connector
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &connector))
+	assert.Equal(t, redactedSecret, connector.Config.Password)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/connectors", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "hunter2")
+	assert.NotContains(t, rr.Body.String(), "tok3n")
+	assert.Contains(t, rr.Body.String(), "env:PG_PASSWORD")
+
+	// Redaction only affects responses; the stored connector still connects
+	stored, _ := store.LoadConnector("plain")
+	assert.Equal(t, "hunter2", stored.Config.Password)
+}
+
+func newTestKeyring(t *testing.T, current byte, previous ...byte) *keyring.Keyring {
+	var older [][]byte
+	for _, b := range previous {
+		older = append(older, bytes.Repeat([]byte{b}, 32))
+	}
+	k, err := keyring.New(bytes.Repeat([]byte{current}, 32), older...)
+	assert.NoError(t, err)
+	return k
+}
+
+func TestSaveConnector_EncryptsCredentials(t *testing.T) {
+	defer SetConnectorStore(connectorStore)
+	defer SetKeyring(nil)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+	SetKeyring(newTestKeyring(t, 1))
+
+	assert.NoError(t, saveConnector(&models.Connector{
+		ID:     "encrypted",
+		Type:   "postgres",
+		Config: models.DatabaseConfig{Host: "db", Port: 5432, User: "axis", Password: "hunter2", DBName: "world"},
+	}))
+	assert.NoError(t, saveConnector(&models.Connector{
+		ID:     "referenced",
+		Type:   restConnectorType,
+		Config: models.DatabaseConfig{Auth: &models.AuthConfig{Type: "bearer", Token: "env:API_TOKEN"}},
+	}))
+
+	stored, _ := store.LoadConnector("encrypted")
+	assert.True(t, keyring.IsEncrypted(stored.Config.Password))
+	assert.Equal(t, redactedSecret, newConnectorView(*stored).Config.Password)
+
+	// The password is only decrypted while building the connection string
+	dsn, err := buildConnectionString(stored.Config, stored.Type)
+	assert.NoError(t, err)
+	assert.Contains(t, dsn, "password=hunter2")
+
+	referenced, _ := store.LoadConnector("referenced")
+	assert.Equal(t, "env:API_TOKEN", referenced.Config.Auth.Token)
+
+	SetKeyring(nil)
+	_, err = buildConnectionString(stored.Config, stored.Type)
+	assert.Error(t, err)
+}
+
+func TestRotateConnectorKeys(t *testing.T) {
+	defer SetKeyring(nil)
+	store := storage.NewMemoryStore()
+
+	SetKeyring(newTestKeyring(t, 1))
+	sealed, _ := sealSecrets(models.Connector{ID: "old", Config: models.DatabaseConfig{Password: "hunter2"}})
+	assert.NoError(t, store.SaveConnector(&sealed))
+	assert.NoError(t, store.SaveConnector(&models.Connector{ID: "plaintext", Config: models.DatabaseConfig{Password: "letmein"}}))
+
+	SetKeyring(newTestKeyring(t, 2, 1))
+	count, err := RotateConnectorKeys(store)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Only the new key is needed afterwards
+	SetKeyring(newTestKeyring(t, 2))
+	for id, password := range map[string]string{"old": "hunter2", "plaintext": "letmein"} {
+		connector, _ := store.LoadConnector(id)
+		resolved, err := resolveSecrets(connector.Config)
+		assert.NoError(t, err, id)
+		assert.Equal(t, password, resolved.Password, id)
+	}
+
+	// Without the previous key nothing can be re-encrypted
+	SetKeyring(newTestKeyring(t, 3))
+	_, err = RotateConnectorKeys(store)
+	assert.ErrorIs(t, err, keyring.ErrUnknownKey)
+}
+
+func TestUpdateConnector_KeepsStoredSecrets(t *testing.T) {
+	defer SetConnectorStore(connectorStore)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/connectors/:id", UpdateConnector)
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/connectors/warehouse", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	storedPassword := func() string {
+		connector, _ := store.LoadConnector("warehouse")
+		return connector.Config.Password
+	}
+
+	assert.NoError(t, store.SaveConnector(&models.Connector{
+		ID: "warehouse", Type: "postgres",
+		Config: models.DatabaseConfig{Host: "db", Port: 5432, User: "axis", Password: "hunter2", DBName: "world"},
+	}))
+
+	// Omitted and masked passwords keep the stored one
+	rr := update(`{"name": "renamed", "type": "postgres", "config": {"host": "db", "port": 5432, "user": "axis", "dbname": "world"}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "hunter2")
+	assert.Equal(t, "hunter2", storedPassword())
+
+	rr = update(`{"type": "postgres", "config": {"host": "db", "port": 5432, "user": "axis", "password": "********", "dbname": "world"}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "hunter2", storedPassword())
+
+	// A new password replaces it
+	rr = update(`{"type": "postgres", "config": {"host": "db", "port": 5432, "user": "axis", "password": "correct horse", "dbname": "world"}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "correct horse", storedPassword())
+
+	// The stored password is not carried to another host
+	rr = update(`{"type": "postgres", "config": {"host": "elsewhere", "port": 5432, "user": "axis", "password": "********", "dbname": "world"}}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "correct horse", storedPassword())
+}
+
+func TestConnectorHeaders_AreSecrets(t *testing.T) {
+	defer SetConnectorStore(connectorStore)
+	defer SetKeyring(nil)
+	store := storage.NewMemoryStore()
+	SetConnectorStore(store)
+	SetKeyring(newTestKeyring(t, 1))
+
+	connector := &models.Connector{
+		ID: "api", Type: restConnectorType,
+		Config: models.DatabaseConfig{BaseURL: "https://api.example", Headers: map[string]string{"X-Api-Key": "k3y"}},
+	}
+	assert.NoError(t, saveConnector(connector))
+	assert.Equal(t, "k3y", connector.Config.Headers["X-Api-Key"], "the caller's connector is not sealed")
+
+	stored, _ := store.LoadConnector("api")
+	assert.True(t, keyring.IsEncrypted(stored.Config.Headers["X-Api-Key"]))
+	assert.Equal(t, redactedSecret, newConnectorView(*stored).Config.Headers["X-Api-Key"])
+
+	resolved, err := resolveSecrets(stored.Config)
+	assert.NoError(t, err)
+	assert.Equal(t, "k3y", resolved.Headers["X-Api-Key"])
+
+	assert.Equal(t, "rejected ********", sanitizeDriverMessage(errors.New("rejected k3y"), stored.Config))
+
+	// Masked or omitted headers keep the stored values
+	for _, headers := range []map[string]string{{"X-Api-Key": redactedSecret}, nil} {
+		update := &models.Connector{Type: restConnectorType, Config: models.DatabaseConfig{BaseURL: "https://api.example", Headers: headers}}
+		assert.NoError(t, keepStoredSecrets(update, stored))
+		assert.Equal(t, stored.Config.Headers["X-Api-Key"], update.Config.Headers["X-Api-Key"])
+	}
+
+	update := &models.Connector{Type: restConnectorType, Config: models.DatabaseConfig{BaseURL: "https://attacker.example"}}
+	assert.Error(t, keepStoredSecrets(update, stored))
+}
+
+func TestKeepStoredSecrets_OmittedAuth(t *testing.T) {
+	stored := &models.Connector{
+		Type: restConnectorType,
+		Config: models.DatabaseConfig{
+			BaseURL: "https://api.example",
+			Auth:    &models.AuthConfig{Type: "basic", Username: "axis", Password: "hunter2"},
+		},
+	}
+
+	update := &models.Connector{Type: restConnectorType, Config: models.DatabaseConfig{BaseURL: "https://api.example"}}
+	assert.NoError(t, keepStoredSecrets(update, stored))
+	assert.Equal(t, &models.AuthConfig{Type: "basic", Username: "axis", Password: "hunter2"}, update.Config.Auth)
+
+	// Keeping the auth object must not share it with the stored connector
+	update.Config.Auth.Password = "changed"
+	assert.Equal(t, "hunter2", stored.Config.Auth.Password)
+
+	update = &models.Connector{Type: restConnectorType, Config: models.DatabaseConfig{BaseURL: "https://attacker.example"}}
+	assert.Error(t, keepStoredSecrets(update, stored))
+}
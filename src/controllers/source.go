@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"axis/src/dialect"
+	"axis/src/models"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rowSource yields the rows a contract reads from its connector
+type rowSource interface {
+	// columnTypes describes the result columns when the source knows them
+	columnTypes() []*sql.ColumnType
+	next() bool
+	row() (map[string]any, error)
+	err() error
+	close() error
+}
+
+// sourceError is a failure to open a row source, answered with its own status
+type sourceError struct {
+	status  int
+	message string
+}
+
+func (e *sourceError) Error() string { return e.message }
+
+func respondSourceError(c *gin.Context, err error) {
+	if se, ok := err.(*sourceError); ok {
+		if se.status == statusClientClosedRequest {
+			c.Abort()
+			return
+		}
+		c.JSON(se.status, gin.H{"error": se.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// queryTimeout returns the time limit for running a contract query, or zero
+// when neither the connector nor the contract sets one. A connector's limit
+// applies to all of its contracts, which may shorten it but never extend it.
+func queryTimeout(connector *models.Connector, query models.DatabaseQuery) time.Duration {
+	timeout := time.Duration(connector.TimeoutSec) * time.Second
+	if query.TimeoutSec > 0 {
+		if contractTimeout := time.Duration(query.TimeoutSec) * time.Second; timeout == 0 || contractTimeout < timeout {
+			timeout = contractTimeout
+		}
+	}
+	return timeout
+}
+
+// withFirstRowDeadline derives a context that ends when the timeout passes
+// before stop is called. Execution stops it once the first row arrives, so a
+// slow query is cut off but a long result still streams to the end. A zero
+// timeout sets no limit.
+func withFirstRowDeadline(parent context.Context, timeout time.Duration) (ctx context.Context, stop func(), cancel func()) {
+	ctx, cancelCause := context.WithCancelCause(parent)
+	if timeout <= 0 {
+		return ctx, func() {}, func() { cancelCause(nil) }
+	}
+	timer := time.AfterFunc(timeout, func() { cancelCause(context.DeadlineExceeded) })
+	return ctx, func() { timer.Stop() }, func() {
+		timer.Stop()
+		cancelCause(nil)
+	}
+}
+
+// defaultConnectTimeout bounds connection tests and catalog reads when the
+// connector sets no timeout
+const defaultConnectTimeout = 30 * time.Second
+
+// connectTimeout returns the time limit for testing or describing a connector
+func connectTimeout(connector *models.Connector) time.Duration {
+	if connector.TimeoutSec > 0 {
+		return time.Duration(connector.TimeoutSec) * time.Second
+	}
+	return defaultConnectTimeout
+}
+
+// contextError turns the end of a request context into a source error: a
+// timeout when the deadline passed, nil while the context is still live
+func contextError(ctx context.Context) error {
+	switch {
+	case ctx.Err() == nil:
+		return nil
+	case errors.Is(context.Cause(ctx), context.DeadlineExceeded):
+		return &sourceError{http.StatusGatewayTimeout, "Query timed out"}
+	default:
+		return &sourceError{statusClientClosedRequest, "Request canceled"}
+	}
+}
+
+// statusClientClosedRequest marks a request whose client went away; nothing
+// is written for it
+const statusClientClosedRequest = 499
+
+// openRowSource runs the contract query against its connector. The query is
+// canceled when ctx ends.
+func openRowSource(ctx context.Context, connector *models.Connector, query models.DatabaseQuery, params map[string]any) (rowSource, error) {
+	switch connector.Type {
+	case fileConnectorType:
+		return openFileSource(ctx, connector, query)
+	case restConnectorType:
+		return openRESTSource(ctx, connector, query, params)
+	default:
+		return openSQLSource(ctx, connector, query, params)
+	}
+}
+
+// sqlSource streams the rows of a SQL query run in a read-only transaction
+type sqlSource struct {
+	conn    *sql.Conn
+	rows    *sql.Rows
+	columns []string
+	types   []*sql.ColumnType
+}
+
+// rollbackTimeout bounds ending the read-only transaction once the rows are read
+const rollbackTimeout = 5 * time.Second
+
+func openSQLSource(ctx context.Context, connector *models.Connector, query models.DatabaseQuery, params map[string]any) (rowSource, error) {
+	d, ok := dialect.Lookup(connector.Type)
+	if !ok {
+		return nil, &sourceError{http.StatusBadRequest, fmt.Sprintf("Unsupported connector type %q", connector.Type)}
+	}
+	// Contracts stored before queries were validated are checked again here
+	if err := validateReadOnlyQuery(query.SQLQuery); err != nil {
+		return nil, &sourceError{http.StatusBadRequest, "Contract query is not read-only: " + err.Error()}
+	}
+
+	db, err := pools.get(connector, d)
+	if err != nil {
+		logConnectorError(connector, "opening pool", err)
+		return nil, &sourceError{http.StatusInternalServerError, "Database connection failed"}
+	}
+
+	baseQuery, values := bindParameters(query.SQLQuery, params, connector.Type)
+	whereClause, filterValues := buildWhereClause(query.Filters, connector.Type, len(values)+1)
+	values = append(values, filterValues...)
+	orderByClause := buildOrderByClause(query.Sort, connector.Type)
+	statement := composeQuery(baseQuery, whereClause, orderByClause, query.Pagination, connector.Type)
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		logConnectorError(connector, "connecting", err)
+		return nil, &sourceError{http.StatusInternalServerError, "Database connection failed"}
+	}
+	if _, err := conn.ExecContext(ctx, d.BeginReadOnly()); err != nil {
+		conn.Close()
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		logConnectorError(connector, "starting read-only transaction", err)
+		return nil, &sourceError{http.StatusInternalServerError, "Failed to start read-only transaction"}
+	}
+
+	rows, err := conn.QueryContext(ctx, statement, values...)
+	if err != nil {
+		endReadOnly(conn)
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		logConnectorError(connector, "running query", err)
+		return nil, &sourceError{http.StatusInternalServerError, "Query execution failed"}
+	}
+
+	columns, _ := rows.Columns()
+	columnTypes, _ := rows.ColumnTypes()
+	return &sqlSource{conn: conn, rows: rows, columns: columns, types: columnTypes}, nil
+}
+
+func (s *sqlSource) columnTypes() []*sql.ColumnType { return s.types }
+func (s *sqlSource) next() bool                     { return s.rows.Next() }
+func (s *sqlSource) err() error                     { return s.rows.Err() }
+
+func (s *sqlSource) row() (map[string]any, error) {
+	return scanRow(s.rows, s.columns, s.types)
+}
+
+// close rolls back the read-only transaction and returns the connection to
+// the connector's pool
+func (s *sqlSource) close() error {
+	err := s.rows.Close()
+	endReadOnly(s.conn)
+	return err
+}
+
+// endReadOnly rolls back the transaction opened on conn and releases it. A
+// connection that cannot roll back is discarded rather than reused while
+// still inside the transaction.
+func endReadOnly(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
+
+// memorySource serves rows that were already read and processed in memory
+type memorySource struct {
+	rows    []map[string]any
+	current int
+}
+
+func newMemorySource(rows []map[string]any) *memorySource {
+	return &memorySource{rows: rows, current: -1}
+}
+
+func (s *memorySource) columnTypes() []*sql.ColumnType { return nil }
+func (s *memorySource) err() error                     { return nil }
+func (s *memorySource) close() error                   { return nil }
+
+func (s *memorySource) next() bool {
+	s.current++
+	return s.current < len(s.rows)
+}
+
+func (s *memorySource) row() (map[string]any, error) {
+	return s.rows[s.current], nil
+}
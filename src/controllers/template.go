@@ -0,0 +1,397 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// columnRefPattern matches a template that is nothing but a single column reference
+var columnRefPattern = regexp.MustCompile(`^\s*\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}\s*$`)
+
+// compiledTemplate is a response template parsed once per execution and then
+// rendered for every row.
+type compiledTemplate struct {
+	typed    bool
+	groupBy  []string
+	root     *templateNode
+	collects []*templateNode // Collect nodes in a stable order
+}
+
+type nodeKind int
+
+const (
+	nodeObject  nodeKind = iota // Nested JSON object
+	nodeArray                   // Nested JSON array
+	nodeText                    // Go template rendered to a string
+	nodeColumn                  // Direct column reference in typed mode
+	nodeLiteral                 // Non-string value emitted as-is in typed mode
+	nodeCollect                 // Array with one child per row of a group
+)
+
+// collectDirective marks a template object whose value is rendered once per
+// row of a group and collected into an array. With "distinct": true next to
+// it, identical children are kept once.
+const (
+	collectDirective = "$collect"
+	distinctOption   = "distinct"
+)
+
+// templateRefPattern finds column references inside a Go template string
+var templateRefPattern = regexp.MustCompile(`\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+type templateNode struct {
+	kind     nodeKind
+	keys     []string // Object keys in sorted order
+	fields   map[string]*templateNode
+	items    []*templateNode
+	column   string
+	literal  any
+	execute  func(data any) (string, error)
+	rule     *models.AnonymizationRule
+	child    *templateNode // Template rendered per row by a collect node
+	columns  []string      // Columns read by the child of a collect node
+	distinct bool          // Whether a collect node drops identical children
+}
+
+// compileTemplate parses a response template. Nested objects and arrays are
+// rendered recursively. In the default mode each leaf is rendered as an
+// HTML-escaped string, as it always has been. In typed mode a leaf that is
+// exactly {{.column}} keeps the column's native JSON type, SQL NULL becomes
+// null and interpolation skips HTML escaping.
+//
+// Anonymization rules name a field by its path, e.g. "address.city". Array
+// elements share the path of their array, so "contacts.email" covers the
+// email of every contact.
+//
+// When groupBy is set, rows with the same values in those columns produce a
+// single object. Its fields come from the first row of the group, and every
+// {"$collect": {...}} object becomes an array with one entry per row.
+func compileTemplate(rt models.ResponseTemplate) (*compiledTemplate, error) {
+	switch rt.Mode {
+	case models.TemplateModeString, models.TemplateModeTyped:
+	default:
+		return nil, fmt.Errorf("unknown template mode %q", rt.Mode)
+	}
+
+	t := &compiledTemplate{typed: rt.Mode == models.TemplateModeTyped, groupBy: rt.GroupBy}
+
+	rules := make(map[string]models.AnonymizationRule)
+	for _, rule := range rt.Anonymization {
+		if _, exists := rules[rule.Field]; !exists {
+			rules[rule.Field] = rule
+		}
+	}
+
+	root, err := t.compileNode(rt.Template, "", rules, false)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil || root.kind != nodeObject {
+		root = &templateNode{kind: nodeObject, fields: map[string]*templateNode{}}
+	}
+	t.root = root
+	t.collects = t.collectNodes()
+	return t, nil
+}
+
+// compileNode returns nil for values the current mode does not render
+func (t *compiledTemplate) compileNode(value any, path string, rules map[string]models.AnonymizationRule, inCollect bool) (*templateNode, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		if child, ok := v[collectDirective]; ok {
+			return t.compileCollect(child, v, path, rules, inCollect)
+		}
+
+		node := &templateNode{kind: nodeObject, fields: make(map[string]*templateNode, len(v))}
+		for key, child := range v {
+			childNode, err := t.compileNode(child, joinPath(path, key), rules, inCollect)
+			if err != nil {
+				return nil, err
+			}
+			if childNode != nil {
+				node.fields[key] = childNode
+				node.keys = append(node.keys, key)
+			}
+		}
+		sort.Strings(node.keys)
+		return node, nil
+
+	case []any:
+		node := &templateNode{kind: nodeArray}
+		for _, item := range v {
+			itemNode, err := t.compileNode(item, path, rules, inCollect)
+			if err != nil {
+				return nil, err
+			}
+			if itemNode != nil {
+				node.items = append(node.items, itemNode)
+			}
+		}
+		return node, nil
+
+	case string:
+		node := &templateNode{}
+		if m := columnRefPattern.FindStringSubmatch(v); t.typed && m != nil {
+			node.kind = nodeColumn
+			node.column = m[1]
+		} else {
+			execute, err := t.parse(v)
+			if err != nil {
+				return nil, err
+			}
+			node.kind = nodeText
+			node.execute = execute
+		}
+		if rule, ok := rules[path]; ok {
+			node.rule = &rule
+		}
+		return node, nil
+
+	default:
+		// The string mode has always skipped non-string values
+		if !t.typed {
+			return nil, nil
+		}
+		node := &templateNode{kind: nodeLiteral, literal: v}
+		if rule, ok := rules[path]; ok {
+			node.rule = &rule
+		}
+		return node, nil
+	}
+}
+
+func (t *compiledTemplate) compileCollect(child any, directive map[string]any, path string, rules map[string]models.AnonymizationRule, inCollect bool) (*templateNode, error) {
+	switch {
+	case len(t.groupBy) == 0:
+		return nil, fmt.Errorf("%s at %q requires groupBy", collectDirective, path)
+	case inCollect:
+		return nil, fmt.Errorf("%s at %q cannot be nested inside another %s", collectDirective, path, collectDirective)
+	}
+
+	distinct := false
+	for key, value := range directive {
+		switch key {
+		case collectDirective:
+		case distinctOption:
+			flag, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s of %s at %q must be true or false", distinctOption, collectDirective, path)
+			}
+			distinct = flag
+		default:
+			return nil, fmt.Errorf("%s at %q allows only %q next to it", collectDirective, path, distinctOption)
+		}
+	}
+
+	childNode, err := t.compileNode(child, path, rules, true)
+	if err != nil {
+		return nil, err
+	}
+	if childNode == nil {
+		return nil, fmt.Errorf("%s at %q has nothing to render", collectDirective, path)
+	}
+	return &templateNode{kind: nodeCollect, child: childNode, columns: referencedColumns(child), distinct: distinct}, nil
+}
+
+// referencedColumns lists the columns a template value reads from
+func referencedColumns(value any) []string {
+	var columns []string
+	switch v := value.(type) {
+	case map[string]any:
+		for _, child := range v {
+			columns = append(columns, referencedColumns(child)...)
+		}
+	case []any:
+		for _, item := range v {
+			columns = append(columns, referencedColumns(item)...)
+		}
+	case string:
+		for _, m := range templateRefPattern.FindAllStringSubmatch(v, -1) {
+			columns = append(columns, m[1])
+		}
+	}
+	return columns
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func (t *compiledTemplate) parse(tmpl string) (func(data any) (string, error), error) {
+	var execute func(w io.Writer, data any) error
+	if t.typed {
+		parsed, err := texttemplate.New("field").Parse(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		execute = parsed.Execute
+	} else {
+		parsed, err := htmltemplate.New("field").Parse(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		execute = parsed.Execute
+	}
+
+	return func(data any) (string, error) {
+		var buf bytes.Buffer
+		if err := execute(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}, nil
+}
+
+// render produces the response object for a single result row
+func (t *compiledTemplate) render(row map[string]any) (map[string]any, error) {
+	data := any(row)
+	if t.typed {
+		data = interpolationData(row)
+	}
+
+	result, err := t.root.render(row, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]any), nil
+}
+
+// renderGroup renders a group of rows using the collected children
+func (t *compiledTemplate) renderGroup(first map[string]any, collected map[*templateNode][]any) (map[string]any, error) {
+	data := any(first)
+	if t.typed {
+		data = interpolationData(first)
+	}
+
+	result, err := t.root.render(first, data, collected)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]any), nil
+}
+
+// collectNodes lists the collect nodes of the template in a stable order
+func (t *compiledTemplate) collectNodes() []*templateNode {
+	var nodes []*templateNode
+	var walk func(n *templateNode)
+	walk = func(n *templateNode) {
+		switch n.kind {
+		case nodeObject:
+			for _, key := range n.keys {
+				walk(n.fields[key])
+			}
+		case nodeArray:
+			for _, item := range n.items {
+				walk(item)
+			}
+		case nodeCollect:
+			nodes = append(nodes, n)
+		}
+	}
+	walk(t.root)
+	return nodes
+}
+
+func (n *templateNode) render(row map[string]any, data any, collected map[*templateNode][]any) (any, error) {
+	var value any
+	switch n.kind {
+	case nodeObject:
+		obj := make(map[string]any, len(n.fields))
+		for _, key := range n.keys {
+			v, err := n.fields[key].render(row, data, collected)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = v
+		}
+		return obj, nil
+	case nodeArray:
+		arr := make([]any, 0, len(n.items))
+		for _, item := range n.items {
+			v, err := item.render(row, data, collected)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case nodeText:
+		s, err := n.execute(data)
+		if err != nil {
+			return nil, err
+		}
+		value = s
+	case nodeColumn:
+		value = row[n.column]
+	case nodeLiteral:
+		value = n.literal
+	case nodeCollect:
+		items := collected[n]
+		if items == nil {
+			items = []any{}
+		}
+		return items, nil
+	}
+
+	if n.rule != nil {
+		value = anonymizeAny(value, *n.rule)
+	}
+	return value, nil
+}
+
+// interpolationData renders SQL NULL as an empty string rather than "<no value>"
+func interpolationData(row map[string]any) map[string]any {
+	data := make(map[string]any, len(row))
+	for col, val := range row {
+		if val == nil {
+			val = ""
+		}
+		data[col] = val
+	}
+	return data
+}
+
+// anonymizeAny applies an anonymization rule to a value of any type. NULL
+// stays NULL, everything else is anonymized through its string form.
+func anonymizeAny(value any, rule models.AnonymizationRule) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return anonymizeValue(v, rule)
+	default:
+		return anonymizeValue(fmt.Sprint(v), rule)
+	}
+}
+
+// convertColumnValue turns raw driver values into types that encode as native
+// JSON. Drivers return DECIMAL, NUMERIC and, over MySQL's text protocol, every
+// number as []byte; those become json.Number so no precision is lost.
+func convertColumnValue(val any, databaseType string) any {
+	b, ok := val.([]byte)
+	if !ok {
+		return val
+	}
+
+	switch strings.ToUpper(databaseType) {
+	case "INT", "INT2", "INT4", "INT8", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT",
+		"UNSIGNED INT", "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED BIGINT",
+		"DECIMAL", "NUMERIC", "MONEY", "SMALLMONEY", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL", "YEAR":
+		if json.Valid(b) {
+			return json.Number(b)
+		}
+	}
+	// Convert []byte to string for MySQL text-based columns
+	return string(b)
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"axis/src/models"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate_StringMode(t *testing.T) {
+	tmpl, err := compileTemplate(models.ResponseTemplate{
+		Template: map[string]any{
+			"name":       "{{.name}}",
+			"population": "{{.population}}",
+			"ignored":    42,
+		},
+	})
+	assert.NoError(t, err)
+
+	result, err := tmpl.render(map[string]any{"name": "O'Brien", "population": int64(5)})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "O&#39;Brien", "population": "5"}, result)
+}
+
+func TestRenderTemplate_TypedMode(t *testing.T) {
+	tmpl, err := compileTemplate(models.ResponseTemplate{
+		Mode: models.TemplateModeTyped,
+		Template: map[string]any{
+			"name":       "{{.name}}",
+			"population": "{{ .population }}",
+			"area":       "{{.area}}",
+			"capital":    "{{.capital}}",
+			"district":   "{{.district}}",
+			"label":      "{{.name}} ({{.district}})",
+			"source":     "world",
+			"version":    2,
+		},
+		Anonymization: []models.AnonymizationRule{
+			{Field: "district", Method: "mask"},
+		},
+	})
+	assert.NoError(t, err)
+
+	row := map[string]any{
+		"name":       "O'Brien",
+		"population": int64(1200),
+		"area":       json.Number("12.50"),
+		"capital":    true,
+		"district":   nil,
+	}
+	result, err := tmpl.render(row)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "O'Brien",
+		"population": 1200,
+		"area": 12.50,
+		"capital": true,
+		"district": null,
+		"label": "O'Brien ()",
+		"source": "world",
+		"version": 2
+	}`, string(data))
+}
+
+func TestCompileTemplate_UnknownMode(t *testing.T) {
+	_, err := compileTemplate(models.ResponseTemplate{Mode: "xml"})
+	assert.Error(t, err)
+}
+
+func TestConvertColumnValue(t *testing.T) {
+	assert.Equal(t, json.Number("42"), convertColumnValue([]byte("42"), "BIGINT"))
+	assert.Equal(t, json.Number("3.14"), convertColumnValue([]byte("3.14"), "numeric"))
+	assert.Equal(t, "Oslo", convertColumnValue([]byte("Oslo"), "VARCHAR"))
+	assert.Equal(t, int64(7), convertColumnValue(int64(7), "INT8"))
+	assert.Nil(t, convertColumnValue(nil, "INT8"))
+}
+
+func TestRenderTemplate_NestedObjectsAndArrays(t *testing.T) {
+	var template map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"name": "{{.name}}",
+		"address": {
+			"city": "{{.city}}",
+			"geo": {"lat": "{{.lat}}", "lon": "{{.lon}}"}
+		},
+		"contacts": [
+			{"type": "email", "value": "{{.email}}"},
+			{"type": "phone", "value": "{{.phone}}"}
+		],
+		"tags": ["{{.continent}}", "{{.region}}"]
+	}`), &template))
+
+	row := map[string]any{
+		"name": "Oslo", "city": "Oslo", "lat": 59.91, "lon": 10.75,
+		"email": "post@oslo.no", "phone": "12345678",
+		"continent": "Europe", "region": "Nordic Countries",
+	}
+
+	tmpl, err := compileTemplate(models.ResponseTemplate{
+		Mode:     models.TemplateModeTyped,
+		Template: template,
+		Anonymization: []models.AnonymizationRule{
+			{Field: "contacts.value", Method: "mask"},
+		},
+	})
+	assert.NoError(t, err)
+
+	result, err := tmpl.render(row)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "Oslo",
+		"address": {"city": "Oslo", "geo": {"lat": 59.91, "lon": 10.75}},
+		"contacts": [
+			{"type": "email", "value": "************"},
+			{"type": "phone", "value": "********"}
+		],
+		"tags": ["Europe", "Nordic Countries"]
+	}`, string(data))
+
+	// The string mode recurses too, rendering every leaf as a string
+	tmpl, err = compileTemplate(models.ResponseTemplate{Template: template})
+	assert.NoError(t, err)
+
+	result, err = tmpl.render(row)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"lat": "59.91", "lon": "10.75"}, result["address"].(map[string]any)["geo"])
+}
@@ -0,0 +1,49 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Catalog holds the queries that describe a database's schemas, tables and
+// columns, so contract authors can browse it without a separate SQL client.
+type Catalog struct {
+	// CurrentSchema selects the schema used when the caller names none
+	CurrentSchema string
+	// Schemas lists schema names, leaving out the database's own
+	Schemas string
+	// Tables binds a schema and returns table name and type ("table" or "view")
+	Tables string
+	// Columns binds a schema and a table and returns, in declared order,
+	// column name, data type, nullable and primary key (both 0 or 1)
+	Columns string
+}
+
+// informationSchemaCatalog builds the catalog queries over the standard
+// information_schema views, hiding the given system schemas
+func informationSchemaCatalog(placeholder func(int) string, currentSchema string, systemSchemas ...string) Catalog {
+	hidden := ""
+	if len(systemSchemas) > 0 {
+		hidden = fmt.Sprintf(" WHERE schema_name NOT IN ('%s')", strings.Join(systemSchemas, "', '"))
+	}
+
+	return Catalog{
+		CurrentSchema: "SELECT " + currentSchema,
+		Schemas:       "SELECT schema_name FROM information_schema.schemata" + hidden + " ORDER BY schema_name",
+		Tables: "SELECT table_name, CASE WHEN table_type = 'VIEW' THEN 'view' ELSE 'table' END" +
+			" FROM information_schema.tables WHERE table_schema = " + placeholder(1) +
+			" ORDER BY table_name",
+		Columns: "SELECT c.column_name, c.data_type," +
+			" CASE WHEN c.is_nullable = 'YES' THEN 1 ELSE 0 END," +
+			" CASE WHEN EXISTS (" +
+			"SELECT 1 FROM information_schema.table_constraints tc" +
+			" JOIN information_schema.key_column_usage k" +
+			" ON k.constraint_schema = tc.constraint_schema AND k.constraint_name = tc.constraint_name AND k.table_name = tc.table_name" +
+			" WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema" +
+			" AND tc.table_name = c.table_name AND k.column_name = c.column_name" +
+			") THEN 1 ELSE 0 END" +
+			" FROM information_schema.columns c" +
+			" WHERE c.table_schema = " + placeholder(1) + " AND c.table_name = " + placeholder(2) +
+			" ORDER BY c.ordinal_position",
+	}
+}
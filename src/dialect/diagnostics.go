@@ -0,0 +1,42 @@
+package dialect
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// Reasons a connection test can fail
+const (
+	FailureDNS             = "dns"
+	FailureRefused         = "refused"
+	FailureAuth            = "auth_failed"
+	FailureDatabaseMissing = "database_missing"
+	FailureTimeout         = "timeout"
+	FailureUnknown         = "unknown"
+)
+
+// ClassifyFailure names the reason a connection attempt failed. Network
+// failures look the same for every driver; the dialect recognizes its
+// server's own error codes.
+func ClassifyFailure(d Dialect, err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.As(err, &dnsErr):
+		return FailureDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureRefused
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	}
+	if d != nil {
+		if reason := d.ClassifyError(err); reason != "" {
+			return reason
+		}
+	}
+	return FailureUnknown
+}
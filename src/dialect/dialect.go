@@ -0,0 +1,135 @@
+// Package dialect describes how each supported database spells the SQL that
+// Axis generates, and how to connect to it. Adding a database means adding one
+// file here that registers its Dialect.
+package dialect
+
+import (
+	"axis/src/models"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Dialect is the SQL syntax and connection details of one connector type
+type Dialect interface {
+	// Driver is the database/sql driver name
+	Driver() string
+	// DSN builds the driver connection string for a connector
+	DSN(config models.DatabaseConfig) string
+
+	// Placeholder returns the bind parameter for the i-th argument (1-based)
+	Placeholder(i int) string
+	// NumberedPlaceholders reports whether a placeholder may be repeated to
+	// bind the same argument twice
+	NumberedPlaceholders() bool
+	// QuoteIdentifier quotes a column or table name
+	QuoteIdentifier(name string) string
+	// WrapQuery turns a contract query into a derived table named axis_src,
+	// so filters, sorting and pagination can follow it
+	WrapQuery(query string) string
+	// Paginate returns the clause that limits a query to one page of rows.
+	// orderBy is the ORDER BY clause already applied to the query, if any.
+	Paginate(orderBy string, limit, offset int) string
+	// CaseInsensitiveLike matches a column against a LIKE pattern ignoring case
+	CaseInsensitiveLike(column, pattern string) string
+	// BooleanLiteral returns the SQL spelling of true or false
+	BooleanLiteral(value bool) string
+	// BackslashEscapes reports whether backslashes escape quotes in literals
+	BackslashEscapes() bool
+	// BeginReadOnly returns the statement that opens the read-only
+	// transaction contract queries run in. The transaction is always rolled
+	// back, so where the database has no read-only mode it still discards
+	// any change.
+	BeginReadOnly() string
+	// Catalog returns the queries that describe the database's tables
+	Catalog() Catalog
+	// ServerInfo returns a query for the server version, current database,
+	// current user and whether the connection uses TLS (1, 0 or NULL when
+	// unknown), or "" when the database offers none
+	ServerInfo() string
+	// ClassifyError names the failure reason of a server error, or returns
+	// "" when it does not recognize the error
+	ClassifyError(err error) string
+}
+
+var (
+	mu       sync.RWMutex
+	dialects = make(map[string]Dialect)
+)
+
+// Register makes a dialect available under a connector type. It panics if the
+// type is already registered.
+func Register(connectorType string, d Dialect) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := dialects[connectorType]; exists {
+		panic(fmt.Sprintf("dialect: Register called twice for %q", connectorType))
+	}
+	dialects[connectorType] = d
+}
+
+// Lookup returns the dialect registered for a connector type
+func Lookup(connectorType string) (Dialect, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	d, ok := dialects[connectorType]
+	return d, ok
+}
+
+// Types lists the registered connector types in sorted order
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	types := make([]string, 0, len(dialects))
+	for t := range dialects {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ANSI provides standard SQL syntax. Dialects embed it and override what
+// their database does differently.
+type ANSI struct{}
+
+func (ANSI) Driver() string                   { return "" }
+func (ANSI) DSN(models.DatabaseConfig) string { return "" }
+func (ANSI) Placeholder(int) string           { return "?" }
+func (ANSI) NumberedPlaceholders() bool       { return false }
+func (ANSI) BackslashEscapes() bool           { return false }
+func (ANSI) BeginReadOnly() string            { return "START TRANSACTION READ ONLY" }
+func (ANSI) ServerInfo() string               { return "" }
+func (ANSI) ClassifyError(error) string       { return "" }
+
+func (a ANSI) Catalog() Catalog {
+	return informationSchemaCatalog(a.Placeholder, "CURRENT_SCHEMA")
+}
+
+func (ANSI) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// WrapQuery selects from the query as a derived table. Newlines keep a
+// trailing "--" comment in the query from swallowing the parenthesis.
+func (ANSI) WrapQuery(query string) string {
+	return "SELECT * FROM (\n" + query + "\n) AS axis_src"
+}
+
+func (ANSI) Paginate(_ string, limit, offset int) string {
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+}
+
+func (ANSI) CaseInsensitiveLike(column, pattern string) string {
+	return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s)", column, pattern)
+}
+
+func (ANSI) BooleanLiteral(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
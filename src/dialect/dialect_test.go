@@ -0,0 +1,121 @@
+package dialect
+
+import (
+	"axis/src/models"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisteredDialects(t *testing.T) {
+	assert.Equal(t, []string{"mysql", "postgres", "sqlite", "sqlserver"}, Types())
+
+	for _, connectorType := range Types() {
+		d, ok := Lookup(connectorType)
+		assert.True(t, ok)
+		assert.NotEmpty(t, d.Driver(), connectorType)
+	}
+
+	_, ok := Lookup("oracle")
+	assert.False(t, ok)
+}
+
+func TestRegister_PanicsOnDuplicate(t *testing.T) {
+	assert.Panics(t, func() { Register("postgres", Postgres{}) })
+}
+
+func TestDialectSyntax(t *testing.T) {
+	tests := []struct {
+		connectorType string
+		placeholder   string
+		quoted        string
+		page          string
+		ilike         string
+		boolean       string
+		begin         string
+	}{
+		{"postgres", "$2", `"a""b"`, " LIMIT 10 OFFSET 20", `"x" ILIKE $1`, "TRUE", "START TRANSACTION READ ONLY"},
+		{"mysql", "?", "`a\"b`", " LIMIT 10 OFFSET 20", "LOWER(`x`) LIKE LOWER(?)", "TRUE", "START TRANSACTION READ ONLY"},
+		{"sqlite", "?", `"a""b"`, " LIMIT 10 OFFSET 20", `LOWER("x") LIKE LOWER(?)`, "1", "BEGIN"},
+		{"sqlserver", "@p2", `[a"b]`, " ORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY", "LOWER([x]) LIKE LOWER(@p1)", "1", "BEGIN TRANSACTION"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.connectorType, func(t *testing.T) {
+			d, _ := Lookup(tt.connectorType)
+			assert.Equal(t, tt.placeholder, d.Placeholder(2))
+			assert.Equal(t, tt.quoted, d.QuoteIdentifier(`a"b`))
+			assert.Equal(t, tt.page, d.Paginate("", 10, 20))
+			assert.Equal(t, tt.ilike, d.CaseInsensitiveLike(d.QuoteIdentifier("x"), d.Placeholder(1)))
+			assert.Equal(t, tt.boolean, d.BooleanLiteral(true))
+			assert.Equal(t, tt.begin, d.BeginReadOnly())
+		})
+	}
+}
+
+func TestDSN(t *testing.T) {
+	config := models.DatabaseConfig{Host: "db", Port: 5432, User: "axis", Password: "secret", DBName: "world"}
+
+	d, _ := Lookup("postgres")
+	assert.Equal(t, "host=db port=5432 user=axis password=secret dbname=world sslmode=disable connect_timeout=10", d.DSN(config))
+
+	d, _ = Lookup("mysql")
+	assert.Equal(t, "axis:secret@tcp(db:5432)/world", d.DSN(config))
+
+	d, _ = Lookup("sqlite")
+	assert.Equal(t, "file:/data/world.db?mode=ro&_pragma=query_only(1)", d.DSN(models.DatabaseConfig{Path: "/data/world.db"}))
+}
+
+func TestCatalog(t *testing.T) {
+	for connectorType, table := range map[string]string{
+		"postgres":  "c.table_name = $2",
+		"mysql":     "c.table_name = ?",
+		"sqlserver": "c.table_name = @p2",
+		"sqlite":    "pragma_table_info(?2, ?1)",
+	} {
+		d, _ := Lookup(connectorType)
+		catalog := d.Catalog()
+		assert.Contains(t, catalog.Columns, table, connectorType)
+		assert.NotEmpty(t, catalog.CurrentSchema, connectorType)
+		assert.NotEmpty(t, catalog.Schemas, connectorType)
+		assert.NotEmpty(t, catalog.Tables, connectorType)
+	}
+
+	d, _ := Lookup("postgres")
+	assert.Contains(t, d.Catalog().Schemas, "NOT IN ('information_schema', 'pg_catalog', 'pg_toast')")
+}
+
+func TestClassifyFailure(t *testing.T) {
+	postgres, _ := Lookup("postgres")
+	mysqlDialect, _ := Lookup("mysql")
+	sqlserver, _ := Lookup("sqlserver")
+
+	tests := []struct {
+		d        Dialect
+		err      error
+		expected string
+	}{
+		{nil, context.DeadlineExceeded, FailureTimeout},
+		{nil, &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "db.invalid"}}, FailureDNS},
+		{nil, &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, FailureRefused},
+		{postgres, &pq.Error{Code: "28P01"}, FailureAuth},
+		{postgres, &pq.Error{Code: "3D000"}, FailureDatabaseMissing},
+		{mysqlDialect, &mysql.MySQLError{Number: 1045}, FailureAuth},
+		{mysqlDialect, &mysql.MySQLError{Number: 1049}, FailureDatabaseMissing},
+		{sqlserver, mssql.Error{Number: 18456}, FailureAuth},
+		{sqlserver, mssql.Error{Number: 4060}, FailureDatabaseMissing},
+		{postgres, &mysql.MySQLError{Number: 1045}, FailureUnknown},
+		{nil, errors.New("boom"), FailureUnknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ClassifyFailure(tt.d, tt.err), tt.err.Error())
+	}
+}
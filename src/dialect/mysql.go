@@ -0,0 +1,56 @@
+package dialect
+
+import (
+	"axis/src/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	Register("mysql", MySQL{})
+}
+
+// MySQL quotes identifiers with backticks and lets backslashes escape quotes
+type MySQL struct{ ANSI }
+
+func (MySQL) Driver() string { return "mysql" }
+
+func (MySQL) DSN(config models.DatabaseConfig) string {
+	return fmt.Sprintf(
+		"%s:%s@tcp(%s:%d)/%s",
+		config.User, config.Password, config.Host, config.Port, config.DBName,
+	)
+}
+
+func (MySQL) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (MySQL) BackslashEscapes() bool { return true }
+
+// Catalog treats databases as schemas, as MySQL does
+func (m MySQL) Catalog() Catalog {
+	return informationSchemaCatalog(m.Placeholder, "DATABASE()", "information_schema", "mysql", "performance_schema", "sys")
+}
+
+func (MySQL) ServerInfo() string {
+	return "SELECT VERSION(), DATABASE(), CURRENT_USER()," +
+		" (SELECT CASE WHEN VARIABLE_VALUE <> '' THEN 1 ELSE 0 END FROM performance_schema.session_status WHERE VARIABLE_NAME = 'Ssl_cipher')"
+}
+
+func (MySQL) ClassifyError(err error) string {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return ""
+	}
+	switch myErr.Number {
+	case 1044, 1045: // ER_DBACCESS_DENIED_ERROR, ER_ACCESS_DENIED_ERROR
+		return FailureAuth
+	case 1049: // ER_BAD_DB_ERROR
+		return FailureDatabaseMissing
+	}
+	return ""
+}
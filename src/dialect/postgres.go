@@ -0,0 +1,59 @@
+package dialect
+
+import (
+	"axis/src/models"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func init() {
+	Register("postgres", Postgres{})
+}
+
+// Postgres uses numbered $n placeholders and a native ILIKE
+type Postgres struct{ ANSI }
+
+func (Postgres) Driver() string { return "postgres" }
+
+// connectTimeout bounds the connection handshake, during which lib/pq does
+// not watch the request context
+const connectTimeout = 10
+
+func (Postgres) DSN(config models.DatabaseConfig) string {
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable connect_timeout=%d",
+		config.Host, config.Port, config.User, config.Password, config.DBName, connectTimeout,
+	)
+}
+
+func (Postgres) Placeholder(i int) string   { return fmt.Sprintf("$%d", i) }
+func (Postgres) NumberedPlaceholders() bool { return true }
+
+func (Postgres) CaseInsensitiveLike(column, pattern string) string {
+	return fmt.Sprintf("%s ILIKE %s", column, pattern)
+}
+
+func (p Postgres) Catalog() Catalog {
+	return informationSchemaCatalog(p.Placeholder, "current_schema()", "information_schema", "pg_catalog", "pg_toast")
+}
+
+func (Postgres) ServerInfo() string {
+	return "SELECT version(), current_database(), current_user," +
+		" (SELECT CASE WHEN ssl THEN 1 ELSE 0 END FROM pg_stat_ssl WHERE pid = pg_backend_pid())"
+}
+
+func (Postgres) ClassifyError(err error) string {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ""
+	}
+	switch pqErr.Code {
+	case "28000", "28P01": // invalid_authorization_specification, invalid_password
+		return FailureAuth
+	case "3D000": // invalid_catalog_name
+		return FailureDatabaseMissing
+	}
+	return ""
+}
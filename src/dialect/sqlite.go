@@ -0,0 +1,62 @@
+package dialect
+
+import (
+	"axis/src/models"
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func init() {
+	Register("sqlite", SQLite{})
+}
+
+// SQLite reads a local database file
+type SQLite struct{ ANSI }
+
+func (SQLite) Driver() string { return "sqlite" }
+
+// DSN opens the file read-only and never creates it
+func (SQLite) DSN(config models.DatabaseConfig) string {
+	return fmt.Sprintf("file:%s?mode=ro&_pragma=query_only(1)", config.Path)
+}
+
+// BooleanLiteral uses integers, which is how SQLite stores booleans
+func (SQLite) BooleanLiteral(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+// BeginReadOnly opens a plain transaction; the connection itself is already
+// read-only
+func (SQLite) BeginReadOnly() string { return "BEGIN" }
+
+// Catalog reads the pragma table functions, as SQLite has no
+// information_schema. Each attached database is a schema.
+func (SQLite) Catalog() Catalog {
+	return Catalog{
+		CurrentSchema: "SELECT 'main'",
+		Schemas:       "SELECT name FROM pragma_database_list WHERE name <> 'temp' ORDER BY seq",
+		Tables: `SELECT name, type FROM pragma_table_list WHERE schema = ?` +
+			` AND type IN ('table', 'view') AND name NOT LIKE 'sqlite\_%' ESCAPE '\' ORDER BY name`,
+		Columns: `SELECT name, type, CASE WHEN "notnull" = 0 AND pk = 0 THEN 1 ELSE 0 END, CASE WHEN pk > 0 THEN 1 ELSE 0 END` +
+			` FROM pragma_table_info(?2, ?1) ORDER BY cid`,
+	}
+}
+
+// ServerInfo reports the library version; a local file has no user or TLS
+func (SQLite) ServerInfo() string {
+	return "SELECT 'SQLite ' || sqlite_version(), 'main', NULL, NULL"
+}
+
+func (SQLite) ClassifyError(err error) string {
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) && liteErr.Code()&0xff == sqlite3.SQLITE_CANTOPEN {
+		return FailureDatabaseMissing
+	}
+	return ""
+}
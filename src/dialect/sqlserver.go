@@ -0,0 +1,210 @@
+package dialect
+
+import (
+	"axis/src/models"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+func init() {
+	Register("sqlserver", SQLServer{})
+}
+
+// SQLServer binds @pN parameters, quotes with brackets and pages with
+// OFFSET ... FETCH
+type SQLServer struct{ ANSI }
+
+func (SQLServer) Driver() string { return "sqlserver" }
+
+func (SQLServer) DSN(config models.DatabaseConfig) string {
+	query := url.Values{}
+	query.Set("database", config.DBName)
+	u := &url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     fmt.Sprintf("%s:%d", config.Host, config.Port),
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+func (SQLServer) Placeholder(i int) string   { return fmt.Sprintf("@p%d", i) }
+func (SQLServer) NumberedPlaceholders() bool { return true }
+
+func (SQLServer) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// WrapQuery works around two T-SQL rules for derived tables: a WITH clause
+// cannot appear inside one, so a leading CTE list is hoisted in front of
+// it, and an ORDER BY needs TOP or OFFSET, so OFFSET 0 ROWS is added to an
+// ORDER BY that has neither.
+func (s SQLServer) WrapQuery(query string) string {
+	words := topLevelWords(query)
+
+	with := ""
+	if len(words) > 0 && words[0].text == "WITH" {
+		// The statement itself starts at the first SELECT outside the CTEs
+		for i, w := range words {
+			if w.text == "SELECT" {
+				with, query, words = query[:w.pos], query[w.pos:], words[i:]
+				for j := range words {
+					words[j].pos -= len(with)
+				}
+				break
+			}
+		}
+	}
+
+	ordered, limited := false, false
+	for i, w := range words {
+		switch w.text {
+		case "ORDER":
+			ordered = ordered || (i+1 < len(words) && words[i+1].text == "BY")
+		case "TOP", "OFFSET":
+			limited = true
+		}
+	}
+	if ordered && !limited {
+		query += "\nOFFSET 0 ROWS"
+	}
+	return with + s.ANSI.WrapQuery(query)
+}
+
+// sqlWord is a keyword or name of a query and its byte offset
+type sqlWord struct {
+	text string
+	pos  int
+}
+
+// topLevelWords returns the upper-cased words of a T-SQL query that are
+// outside parentheses, literals, bracketed names and comments. Variables and
+// qualified names are left out.
+func topLevelWords(query string) []sqlWord {
+	var words []sqlWord
+	depth := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '\'' || c == '"':
+			i = skipDelimited(query, i, c)
+		case c == '[':
+			i = skipDelimited(query, i, ']')
+		case strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			i = skipNestedComment(query, i)
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9':
+			end := i
+			for end+1 < len(query) && isWordChar(query[end+1]) {
+				end++
+			}
+			if depth == 0 && (i == 0 || query[i-1] != '@' && query[i-1] != '.') {
+				words = append(words, sqlWord{text: strings.ToUpper(query[i : end+1]), pos: i})
+			}
+			i = end
+		}
+	}
+	return words
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '@' || c == '#' || c == '$' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// skipDelimited returns the offset of the delimiter closing the literal or
+// name opened at i. A doubled delimiter stands for itself.
+func skipDelimited(query string, i int, closing byte) int {
+	for j := i + 1; j < len(query); j++ {
+		if query[j] != closing {
+			continue
+		}
+		if j+1 < len(query) && query[j+1] == closing {
+			j++
+			continue
+		}
+		return j
+	}
+	return len(query)
+}
+
+// skipNestedComment returns the offset of the last character of the
+// comment opened at i; T-SQL block comments nest
+func skipNestedComment(query string, i int) int {
+	depth := 0
+	for j := i; j < len(query); j++ {
+		switch {
+		case strings.HasPrefix(query[j:], "/*"):
+			depth++
+			j++
+		case strings.HasPrefix(query[j:], "*/"):
+			depth--
+			j++
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(query)
+}
+
+// Paginate adds an ORDER BY when the caller did not sort, because
+// OFFSET ... FETCH is part of the ORDER BY clause
+func (SQLServer) Paginate(orderBy string, limit, offset int) string {
+	clause := fmt.Sprintf(" OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit)
+	if orderBy == "" {
+		clause = " ORDER BY (SELECT NULL)" + clause
+	}
+	return clause
+}
+
+// BooleanLiteral uses BIT values, as T-SQL has no boolean literals
+func (SQLServer) BooleanLiteral(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+// BeginReadOnly opens a plain transaction, as SQL Server has no read-only
+// transactions. Contract queries are rolled back, and the login should only
+// be granted read access.
+func (SQLServer) BeginReadOnly() string { return "BEGIN TRANSACTION" }
+
+func (s SQLServer) Catalog() Catalog {
+	return informationSchemaCatalog(s.Placeholder, "SCHEMA_NAME()",
+		"INFORMATION_SCHEMA", "sys", "guest", "db_owner", "db_accessadmin", "db_securityadmin", "db_ddladmin",
+		"db_backupoperator", "db_datareader", "db_datawriter", "db_denydatareader", "db_denydatawriter")
+}
+
+func (SQLServer) ServerInfo() string {
+	return "SELECT @@VERSION, DB_NAME(), SUSER_SNAME()," +
+		" (SELECT CASE WHEN encrypt_option = 'TRUE' THEN 1 ELSE 0 END FROM sys.dm_exec_connections WHERE session_id = @@SPID)"
+}
+
+func (SQLServer) ClassifyError(err error) string {
+	var msErr mssql.Error
+	if !errors.As(err, &msErr) {
+		return ""
+	}
+	switch msErr.Number {
+	case 18456: // Login failed
+		return FailureAuth
+	case 4060: // Cannot open database requested by the login
+		return FailureDatabaseMissing
+	}
+	return ""
+}
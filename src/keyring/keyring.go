@@ -0,0 +1,147 @@
+// Package keyring encrypts connector credentials at rest with AES-GCM. Each
+// value records which key sealed it, so keys can be rotated while values
+// sealed with the previous key are still readable.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefix marks a sealed value: enc:v1:<key id>:<base64 nonce and ciphertext>
+const prefix = "enc:v1:"
+
+var ErrUnknownKey = errors.New("value was encrypted with a key that is not configured")
+
+// Config names the keys, each given inline or as a file holding it. Keys are
+// 32 random bytes, base64 encoded.
+type Config struct {
+	Key             string
+	KeyFile         string
+	PreviousKey     string
+	PreviousKeyFile string
+}
+
+// Keyring seals values with its current key and opens values sealed with
+// the current or the previous key
+type Keyring struct {
+	current *key
+	keys    map[string]*key
+}
+
+type key struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Open builds the keyring described by the configuration. It returns nil
+// when no key is configured.
+func Open(cfg Config) (*Keyring, error) {
+	current, err := readKey(cfg.Key, cfg.KeyFile)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	previous, err := readKey(cfg.PreviousKey, cfg.PreviousKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return New(current)
+	}
+	return New(current, previous)
+}
+
+// New creates a keyring that seals with current and also opens values
+// sealed with any of the previous keys
+func New(current []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*key)}
+	for i, raw := range append([][]byte{current}, previous...) {
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(raw))
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(raw)
+		entry := &key{id: hex.EncodeToString(sum[:4]), aead: aead}
+		if i == 0 {
+			k.current = entry
+		}
+		k.keys[entry.id] = entry
+	}
+	return k, nil
+}
+
+// IsEncrypted reports whether a value was sealed by a keyring
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt seals a value with the current key
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, k.current.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := k.current.aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.current.id))
+	return prefix + k.current.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt with any key of the keyring
+func (k *Keyring) Decrypt(value string) (string, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !IsEncrypted(value) || !ok {
+		return "", errors.New("value is not encrypted")
+	}
+	entry, ok := k.keys[id]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < entry.aead.NonceSize() {
+		return "", errors.New("encrypted value is malformed")
+	}
+	nonce, ciphertext := sealed[:entry.aead.NonceSize()], sealed[entry.aead.NonceSize():]
+	plaintext, err := entry.aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", errors.New("encrypted value failed authentication")
+	}
+	return string(plaintext), nil
+}
+
+// readKey decodes a key given inline or in a file; neither yields nil
+func readKey(inline, file string) ([]byte, error) {
+	encoded := inline
+	if encoded == "" && file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key file %s is not readable", file)
+		}
+		encoded = string(data)
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("encryption key must be base64 encoded")
+	}
+	return raw, nil
+}
@@ -0,0 +1,82 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte{1}, 32)
+	newKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	k, err := New(newKey)
+	assert.NoError(t, err)
+
+	sealed, err := k.Encrypt("hunter2")
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(sealed))
+	assert.NotContains(t, sealed, "hunter2")
+
+	again, _ := k.Encrypt("hunter2")
+	assert.NotEqual(t, sealed, again, "every value gets a fresh nonce")
+
+	plaintext, err := k.Decrypt(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	// Tampering is detected
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if tampered == sealed {
+		tampered = sealed[:len(sealed)-2] + "BB"
+	}
+	_, err = k.Decrypt(tampered)
+	assert.Error(t, err)
+
+	_, err = k.Decrypt("hunter2")
+	assert.Error(t, err)
+}
+
+func TestRotation(t *testing.T) {
+	old, _ := New(oldKey)
+	sealed, _ := old.Encrypt("hunter2")
+
+	rotated, err := New(newKey, oldKey)
+	assert.NoError(t, err)
+	plaintext, err := rotated.Decrypt(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	resealed, _ := rotated.Encrypt(plaintext)
+	current, _ := New(newKey)
+	_, err = current.Decrypt(resealed)
+	assert.NoError(t, err)
+	_, err = current.Decrypt(sealed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestOpen(t *testing.T) {
+	k, err := Open(Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, k, "no key means no encryption")
+
+	file := filepath.Join(t.TempDir(), "key")
+	assert.NoError(t, os.WriteFile(file, []byte(base64.StdEncoding.EncodeToString(newKey)+"\n"), 0o600))
+	k, err = Open(Config{KeyFile: file, PreviousKey: base64.StdEncoding.EncodeToString(oldKey)})
+	assert.NoError(t, err)
+	assert.Len(t, k.keys, 2)
+
+	_, err = Open(Config{Key: base64.StdEncoding.EncodeToString([]byte("too short"))})
+	assert.Error(t, err)
+	_, err = Open(Config{Key: "not base64!"})
+	assert.Error(t, err)
+	_, err = Open(Config{KeyFile: filepath.Join(t.TempDir(), "missing")})
+	assert.True(t, err != nil && strings.Contains(err.Error(), "not readable"))
+}
@@ -1,13 +1,66 @@
 package main
 
 import (
+	"axis/src/controllers"
+	"axis/src/keyring"
 	"axis/src/routes"
+	"axis/src/storage"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Select the storage backend for contracts and connectors
+	store, err := storage.Open(storage.Config{
+		Backend:       os.Getenv("STORAGE_BACKEND"),
+		DSN:           os.Getenv("STORAGE_DSN"),
+		ContractsDir:  getEnv("CONTRACTS_DIR", "../data-contracts"),
+		ConnectorsDir: getEnv("CONNECTORS_DIR", "../connectors"),
+	})
+	if err != nil {
+		panic(err)
+	}
+	controllers.SetContractStore(store)
+	controllers.SetConnectorStore(store)
+
+	// Encrypt connector credentials at rest when a key is configured
+	keys, err := keyring.Open(keyring.Config{
+		Key:             os.Getenv("ENCRYPTION_KEY"),
+		KeyFile:         os.Getenv("ENCRYPTION_KEY_FILE"),
+		PreviousKey:     os.Getenv("PREVIOUS_ENCRYPTION_KEY"),
+		PreviousKeyFile: os.Getenv("PREVIOUS_ENCRYPTION_KEY_FILE"),
+	})
+	if err != nil {
+		panic(err)
+	}
+	controllers.SetKeyring(keys)
+
+	// Connectors may only reference secrets set aside for them
+	policy := controllers.SecretPolicy{Dir: os.Getenv("SECRETS_DIR")}
+	for _, prefix := range strings.Split(os.Getenv("SECRET_ENV_PREFIXES"), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			policy.EnvPrefixes = append(policy.EnvPrefixes, prefix)
+		}
+	}
+	controllers.SetSecretPolicy(policy)
+
+	// File and sqlite connectors may only read below the data directory
+	controllers.SetConnectorDataDir(os.Getenv("CONNECTOR_DATA_DIR"))
+
+	// "rotate-key" re-encrypts the stored connectors with the current key and exits
+	if len(os.Args) > 1 && os.Args[1] == "rotate-key" {
+		count, err := controllers.RotateConnectorKeys(store)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "key rotation stopped after %d connectors: %v\n", count, err)
+			os.Exit(1)
+		}
+		fmt.Printf("re-encrypted %d connectors\n", count)
+		return
+	}
+
 	router := gin.Default()
 
 	// Set up middleware here if needed
@@ -16,14 +69,19 @@ func main() {
 	routes.SetupRoutes(router)
 
 	// Get port from environment variable or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := getEnv("PORT", "8080")
 
 	// Start the server
-	err := router.Run(":" + port)
+	err = router.Run(":" + port)
 	if err != nil {
 		panic(err)
 	}
 }
+
+// getEnv returns the value of an environment variable or a fallback when unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // DatabaseConfig represents the database connection configuration
 type DatabaseConfig struct {
 	Host     string `json:"host"`
@@ -7,6 +9,20 @@ type DatabaseConfig struct {
 	User     string `json:"user"`
 	Password string `json:"password"`
 	DBName   string `json:"dbname"`
+	Path     string `json:"path,omitempty"` // Database file for the sqlite type, directory for the file type
+
+	// Upstream service for the rest type
+	BaseURL string            `json:"baseUrl,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"` // Sent with every request
+	Auth    *AuthConfig       `json:"auth,omitempty"`
+}
+
+// AuthConfig describes how a rest connector authenticates upstream
+type AuthConfig struct {
+	Type     string `json:"type"`               // "basic" or "bearer"
+	Username string `json:"username,omitempty"` // For basic auth
+	Password string `json:"password,omitempty"` // For basic auth
+	Token    string `json:"token,omitempty"`    // For bearer auth
 }
 
 // Connector represents the database connection configuration
@@ -16,6 +32,51 @@ type Connector struct {
 	Description string         `json:"description"`
 	Type        string         `json:"type"`
 	Config      DatabaseConfig `json:"config"`
+	Pool        *PoolConfig    `json:"pool,omitempty"`
+	TimeoutSec  int            `json:"timeoutSec,omitempty"` // Upper bound for any query through this connector
+}
+
+// ConnectorView is a connector as the API shows it. Plaintext and encrypted
+// credentials are masked; secret references are shown as they are.
+type ConnectorView struct {
+	Connector
+}
+
+// ConnectionDiagnostics reports the outcome of testing a connector
+type ConnectionDiagnostics struct {
+	Status        string  `json:"status,omitempty"`  // Set when the connection works
+	Error         string  `json:"error,omitempty"`   // Set when it does not
+	Failure       string  `json:"failure,omitempty"` // dns, refused, auth_failed, database_missing, timeout or unknown
+	Message       string  `json:"message,omitempty"` // The driver's error with credentials removed
+	LatencyMs     float64 `json:"latencyMs,omitempty"`
+	ServerVersion string  `json:"serverVersion,omitempty"`
+	Database      string  `json:"database,omitempty"`
+	User          string  `json:"user,omitempty"`
+	TLS           *bool   `json:"tls,omitempty"` // Unset when not known or not applicable
+}
+
+// TableInfo describes a table or view found by introspecting a connector
+type TableInfo struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Type   string `json:"type"` // "table" or "view"
+}
+
+// ColumnInfo describes a column of an introspected table
+type ColumnInfo struct {
+	Name       string `json:"name"`
+	DataType   string `json:"dataType"` // As the database names it
+	Nullable   bool   `json:"nullable"`
+	PrimaryKey bool   `json:"primaryKey"`
+}
+
+// PoolConfig bounds the connections Axis keeps open to a SQL connector.
+// Zero values use the defaults.
+type PoolConfig struct {
+	MaxOpenConns       int `json:"maxOpenConns,omitempty"`
+	MaxIdleConns       int `json:"maxIdleConns,omitempty"`
+	ConnMaxLifetimeSec int `json:"connMaxLifetimeSec,omitempty"`
+	ConnMaxIdleTimeSec int `json:"connMaxIdleTimeSec,omitempty"`
 }
 
 // FilterOperator represents the type of filter operation
@@ -27,6 +88,7 @@ const (
 	OperatorGreater   FilterOperator = "gt"
 	OperatorLess      FilterOperator = "lt"
 	OperatorLike      FilterOperator = "like"
+	OperatorILike     FilterOperator = "ilike" // Case-insensitive LIKE
 	OperatorIn        FilterOperator = "in"
 )
 
@@ -49,17 +111,77 @@ type SortOption struct {
 	Direction string `json:"direction"` // Sort direction ("asc" or "desc")
 }
 
+// FieldType represents the data type of a contract field
+type FieldType string
+
+const (
+	FieldTypeString  FieldType = "string"
+	FieldTypeInteger FieldType = "integer"
+	FieldTypeNumber  FieldType = "number"
+	FieldTypeBoolean FieldType = "boolean"
+	FieldTypeDate    FieldType = "date"
+)
+
+// FieldDefinition declares a result column that callers may filter or sort on
+type FieldDefinition struct {
+	Name       string    `json:"name"`       // Column name in the query result
+	Type       FieldType `json:"type"`       // Data type used to validate filter values
+	Filterable bool      `json:"filterable"` // Whether the field may appear in filters
+	Sortable   bool      `json:"sortable"`   // Whether the field may appear in sort options
+}
+
 // DatabaseQuery represents the query configuration
 type DatabaseQuery struct {
 	ConnectorID string             `json:"connectorId"`
 	SQLQuery    string             `json:"sqlQuery"`
+	Table       string             `json:"table,omitempty"`      // File name without extension for file connectors
+	Request     *RESTRequest       `json:"request,omitempty"`    // Upstream call for rest connectors
+	TimeoutSec  int                `json:"timeoutSec,omitempty"` // Limit for this query, at most the connector's
+	Fields      []FieldDefinition  `json:"fields,omitempty"`     // Allowlist for request filters and sorting
 	Filters     []FilterCondition  `json:"filters,omitempty"`
 	Pagination  *PaginationOptions `json:"pagination,omitempty"`
 	Sort        []SortOption       `json:"sort,omitempty"`
 }
 
+// RESTRequest describes the upstream call a contract makes through a rest connector
+type RESTRequest struct {
+	Path        string `json:"path"`                  // Appended to the base URL; :name is replaced by parameter values
+	Method      string `json:"method,omitempty"`      // GET by default
+	Body        any    `json:"body,omitempty"`        // Sent as JSON
+	RecordsPath string `json:"recordsPath,omitempty"` // JSONPath to the record array, the whole response by default
+
+	// Query parameters that carry filters and pagination upstream. Filters on
+	// fields without a parameter, sorting and pagination without parameters
+	// are applied to the returned records instead.
+	FilterParams  map[string]string `json:"filterParams,omitempty"` // Field name to query parameter, for eq and in filters
+	PageParam     string            `json:"pageParam,omitempty"`
+	PageSizeParam string            `json:"pageSizeParam,omitempty"`
+}
+
+// ParameterDefinition declares a named input that is bound into the contract
+// SQL wherever :name appears
+type ParameterDefinition struct {
+	Name        string    `json:"name"`
+	Type        FieldType `json:"type"`
+	Description string    `json:"description,omitempty"`
+	Required    bool      `json:"required,omitempty"`
+	Default     any       `json:"default,omitempty"`   // Used when the caller omits the parameter
+	Enum        []any     `json:"enum,omitempty"`      // Allowed values
+	Min         *float64  `json:"min,omitempty"`       // Lower bound for integer and number parameters
+	Max         *float64  `json:"max,omitempty"`       // Upper bound for integer and number parameters
+	MinLength   *int      `json:"minLength,omitempty"` // Minimum length for string parameters
+	MaxLength   *int      `json:"maxLength,omitempty"` // Maximum length for string parameters
+}
+
+// ParameterError describes why a single parameter was rejected
+type ParameterError struct {
+	Parameter string `json:"parameter"`
+	Message   string `json:"message"`
+}
+
 // ExecuteContractRequest represents the request body for contract execution
 type ExecuteContractRequest struct {
+	Parameters map[string]any     `json:"parameters,omitempty"`
 	Filters    []FilterCondition  `json:"filters,omitempty"`
 	Pagination *PaginationOptions `json:"pagination,omitempty"`
 	Sort       []SortOption       `json:"sort,omitempty"`
@@ -72,18 +194,29 @@ type AnonymizationRule struct {
 	Pattern string `json:"pattern"` // Optional pattern for masking (e.g., "XXX-XX-****" for SSN)
 }
 
+// Template modes control how response template values are rendered
+const (
+	TemplateModeString = ""      // Every field is rendered as an HTML-escaped string
+	TemplateModeTyped  = "typed" // Column references keep their JSON type and NULL becomes null
+)
+
 // ResponseTemplate represents the template structure for API responses
 type ResponseTemplate struct {
 	ID            string              `json:"id"`
+	Mode          string              `json:"mode,omitempty"`
+	GroupBy       []string            `json:"groupBy,omitempty"` // Columns identifying a parent; rows sharing them are merged
 	Template      map[string]any      `json:"template"`
 	Anonymization []AnonymizationRule `json:"anonymization,omitempty"`
 }
 
 // Contract represents the main contract structure
 type Contract struct {
-	ID               string           `json:"id"`
-	Name             string           `json:"name"`
-	Description      string           `json:"description"`
-	Query            DatabaseQuery    `json:"query"`
-	ResponseTemplate ResponseTemplate `json:"responseTemplate"`
+	ID               string                `json:"id"`
+	Name             string                `json:"name"`
+	Description      string                `json:"description"`
+	Version          int                   `json:"version"`   // Revision number, incremented on every update
+	UpdatedAt        time.Time             `json:"updatedAt"` // When this revision was published
+	Parameters       []ParameterDefinition `json:"parameters,omitempty"`
+	Query            DatabaseQuery         `json:"query"`
+	ResponseTemplate ResponseTemplate      `json:"responseTemplate"`
 }
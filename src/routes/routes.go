@@ -18,17 +18,28 @@ func SetupRoutes(router *gin.Engine) {
 			contracts.PUT("/:id", controllers.UpdateContract)           // Update a contract
 			contracts.DELETE("/:id", controllers.DeleteContract)        // Delete a contract
 			contracts.POST("/:id/execute", controllers.ExecuteContract) // Changed from GET to POST
+
+			// Contract revisions
+			contracts.GET("/:id/versions", controllers.ListContractVersions)    // List all revisions
+			contracts.GET("/:id/versions/:rev", controllers.GetContractVersion) // Get a specific revision
+			contracts.POST("/:id/rollback/:rev", controllers.RollbackContract)  // Republish an earlier revision
 		}
 
 		// Connector routes
 		connectors := api.Group("/connectors")
 		{
-			connectors.POST("", controllers.CreateConnector)        // Create a new connector
-			connectors.GET("", controllers.ListConnectors)          // List all connectors
-			connectors.GET("/:id", controllers.GetConnector)        // Get a specific connector
-			connectors.PUT("/:id", controllers.UpdateConnector)     // Update a connector
-			connectors.DELETE("/:id", controllers.DeleteConnector)  // Delete a connector
-			connectors.GET("/:id/test", controllers.TestConnection) // Test connection
+			connectors.POST("", controllers.CreateConnector)              // Create a new connector
+			connectors.POST("/test", controllers.TestConnectorDefinition) // Test unsaved connection settings
+			connectors.GET("", controllers.ListConnectors)                // List all connectors
+			connectors.GET("/:id", controllers.GetConnector)              // Get a specific connector
+			connectors.PUT("/:id", controllers.UpdateConnector)           // Update a connector
+			connectors.DELETE("/:id", controllers.DeleteConnector)        // Delete a connector
+			connectors.GET("/:id/test", controllers.TestConnection)       // Test connection
+
+			// Schema introspection for contract authoring
+			connectors.GET("/:id/schemas", controllers.ListSchemas)               // List schemas
+			connectors.GET("/:id/tables", controllers.ListTables)                 // List tables of ?schema=
+			connectors.GET("/:id/tables/:table/columns", controllers.ListColumns) // List columns of a table
 		}
 	}
 }
@@ -19,14 +19,21 @@ func TestSetupRoutes(t *testing.T) {
 		// Contract routes
 		{"GET", "/api/contracts/:id"},
 		{"GET", "/api/contracts/:id/execute"},
+		{"GET", "/api/contracts/:id/versions"},
+		{"GET", "/api/contracts/:id/versions/:rev"},
+		{"POST", "/api/contracts/:id/rollback/:rev"},
 
 		// Connector routes
 		{"POST", "/api/connectors"},
+		{"POST", "/api/connectors/test"},
 		{"GET", "/api/connectors"},
 		{"GET", "/api/connectors/:id"},
 		{"PUT", "/api/connectors/:id"},
 		{"DELETE", "/api/connectors/:id"},
 		{"GET", "/api/connectors/:id/test"},
+		{"GET", "/api/connectors/:id/schemas"},
+		{"GET", "/api/connectors/:id/tables"},
+		{"GET", "/api/connectors/:id/tables/:table/columns"},
 	}
 
 	for _, expected := range expectedRoutes {
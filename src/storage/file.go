@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"axis/src/models"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// FileStore keeps every contract and connector as a JSON file on local disk
+type FileStore struct {
+	contractsDir  string
+	connectorsDir string
+}
+
+func NewFileStore(contractsDir, connectorsDir string) *FileStore {
+	return &FileStore{contractsDir: contractsDir, connectorsDir: connectorsDir}
+}
+
+func (s *FileStore) SaveContract(contract *models.Contract) error {
+	return writeJSON(s.contractsDir, contract.ID, contract, 0644)
+}
+
+func (s *FileStore) LoadContract(id string) (*models.Contract, error) {
+	var contract models.Contract
+	if err := readJSON(s.contractsDir, id, &contract, ErrContractNotFound); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+func (s *FileStore) ListContracts() ([]models.Contract, error) {
+	ids, err := listIDs(s.contractsDir)
+	if err != nil {
+		return []models.Contract{}, err
+	}
+
+	var contracts = []models.Contract{}
+	for _, id := range ids {
+		contract, err := s.LoadContract(id)
+		if err != nil {
+			continue
+		}
+		contracts = append(contracts, *contract)
+	}
+	return contracts, nil
+}
+
+func (s *FileStore) DeleteContract(id string) error {
+	if err := removeJSON(s.contractsDir, id, ErrContractNotFound); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.versionsDir(id))
+}
+
+// Revisions live under <contractsDir>/versions/<id>/<version>.json
+func (s *FileStore) versionsDir(id string) string {
+	return filepath.Join(s.contractsDir, "versions", id)
+}
+
+func (s *FileStore) SaveContractVersion(contract *models.Contract) error {
+	data, err := json.Marshal(contract)
+	if err != nil {
+		return err
+	}
+
+	dir := s.versionsDir(contract.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// O_EXCL keeps published revisions immutable, even across replicas
+	filename := filepath.Join(dir, strconv.Itoa(contract.Version)+".json")
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return ErrVersionExists
+		}
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *FileStore) LoadContractVersion(id string, version int) (*models.Contract, error) {
+	var contract models.Contract
+	if err := readJSON(s.versionsDir(id), strconv.Itoa(version), &contract, ErrVersionNotFound); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+func (s *FileStore) ListContractVersions(id string) ([]models.Contract, error) {
+	ids, err := listIDs(s.versionsDir(id))
+	if err != nil {
+		return []models.Contract{}, err
+	}
+
+	var versions []int
+	for _, name := range ids {
+		if v, err := strconv.Atoi(name); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Ints(versions)
+
+	var contracts = []models.Contract{}
+	for _, version := range versions {
+		contract, err := s.LoadContractVersion(id, version)
+		if err != nil {
+			continue
+		}
+		contracts = append(contracts, *contract)
+	}
+	return contracts, nil
+}
+
+// SaveConnector writes the connector readable by its owner only, since it
+// holds credentials
+func (s *FileStore) SaveConnector(connector *models.Connector) error {
+	return writeJSON(s.connectorsDir, connector.ID, connector, 0600)
+}
+
+func (s *FileStore) LoadConnector(id string) (*models.Connector, error) {
+	var connector models.Connector
+	if err := readJSON(s.connectorsDir, id, &connector, ErrConnectorNotFound); err != nil {
+		return nil, err
+	}
+	return &connector, nil
+}
+
+func (s *FileStore) ListConnectors() ([]models.Connector, error) {
+	ids, err := listIDs(s.connectorsDir)
+	if err != nil {
+		return []models.Connector{}, err
+	}
+
+	var connectors = []models.Connector{}
+	for _, id := range ids {
+		connector, err := s.LoadConnector(id)
+		if err != nil {
+			continue
+		}
+		connectors = append(connectors, *connector)
+	}
+	return connectors, nil
+}
+
+func (s *FileStore) DeleteConnector(id string) error {
+	return removeJSON(s.connectorsDir, id, ErrConnectorNotFound)
+}
+
+func writeJSON(dir, id string, v any, perm os.FileMode) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// Create the directory on first write so a fresh deployment needs no setup
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	filename := filepath.Join(dir, id+".json")
+	if err := os.WriteFile(filename, data, perm); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, which may predate perm
+	return os.Chmod(filename, perm)
+}
+
+func readJSON(dir, id string, v any, notFound error) error {
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return notFound
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func removeJSON(dir, id string, notFound error) error {
+	if err := os.Remove(filepath.Join(dir, id+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return notFound
+		}
+		return err
+	}
+	return nil
+}
+
+// listIDs names the JSON files in dir. A directory not created yet, as
+// before the first write, holds none.
+func listIDs(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ids []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		ids = append(ids, file.Name()[:len(file.Name())-5]) // remove .json
+	}
+	return ids, nil
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"axis/src/models"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps contracts and connectors in process memory. Its contents
+// are lost on restart, which makes it mostly useful for tests and demos.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	contracts  map[string][]byte
+	versions   map[string]map[int][]byte
+	connectors map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		contracts:  make(map[string][]byte),
+		versions:   make(map[string]map[int][]byte),
+		connectors: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) SaveContract(contract *models.Contract) error {
+	return s.put(s.contracts, contract.ID, contract)
+}
+
+func (s *MemoryStore) LoadContract(id string) (*models.Contract, error) {
+	var contract models.Contract
+	if err := s.get(s.contracts, id, &contract, ErrContractNotFound); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+func (s *MemoryStore) ListContracts() ([]models.Contract, error) {
+	var contracts = []models.Contract{}
+	for _, id := range s.ids(s.contracts) {
+		contract, err := s.LoadContract(id)
+		if err != nil {
+			continue
+		}
+		contracts = append(contracts, *contract)
+	}
+	return contracts, nil
+}
+
+func (s *MemoryStore) DeleteContract(id string) error {
+	if err := s.remove(s.contracts, id, ErrContractNotFound); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.versions, id)
+	return nil
+}
+
+func (s *MemoryStore) SaveContractVersion(contract *models.Contract) error {
+	data, err := json.Marshal(contract)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, ok := s.versions[contract.ID]
+	if !ok {
+		versions = make(map[int][]byte)
+		s.versions[contract.ID] = versions
+	}
+	if _, exists := versions[contract.Version]; exists {
+		return ErrVersionExists
+	}
+	versions[contract.Version] = data
+	return nil
+}
+
+func (s *MemoryStore) LoadContractVersion(id string, version int) (*models.Contract, error) {
+	s.mu.RLock()
+	data, ok := s.versions[id][version]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrVersionNotFound
+	}
+
+	var contract models.Contract
+	if err := json.Unmarshal(data, &contract); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+func (s *MemoryStore) ListContractVersions(id string) ([]models.Contract, error) {
+	s.mu.RLock()
+	versions := make([]int, 0, len(s.versions[id]))
+	for version := range s.versions[id] {
+		versions = append(versions, version)
+	}
+	s.mu.RUnlock()
+	sort.Ints(versions)
+
+	var contracts = []models.Contract{}
+	for _, version := range versions {
+		contract, err := s.LoadContractVersion(id, version)
+		if err != nil {
+			continue
+		}
+		contracts = append(contracts, *contract)
+	}
+	return contracts, nil
+}
+
+func (s *MemoryStore) SaveConnector(connector *models.Connector) error {
+	return s.put(s.connectors, connector.ID, connector)
+}
+
+func (s *MemoryStore) LoadConnector(id string) (*models.Connector, error) {
+	var connector models.Connector
+	if err := s.get(s.connectors, id, &connector, ErrConnectorNotFound); err != nil {
+		return nil, err
+	}
+	return &connector, nil
+}
+
+func (s *MemoryStore) ListConnectors() ([]models.Connector, error) {
+	var connectors = []models.Connector{}
+	for _, id := range s.ids(s.connectors) {
+		connector, err := s.LoadConnector(id)
+		if err != nil {
+			continue
+		}
+		connectors = append(connectors, *connector)
+	}
+	return connectors, nil
+}
+
+func (s *MemoryStore) DeleteConnector(id string) error {
+	return s.remove(s.connectors, id, ErrConnectorNotFound)
+}
+
+// Values are kept serialized so callers can never mutate stored state
+// through a shared map or slice.
+func (s *MemoryStore) put(m map[string][]byte, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m[id] = data
+	return nil
+}
+
+func (s *MemoryStore) get(m map[string][]byte, id string, v any, notFound error) error {
+	s.mu.RLock()
+	data, ok := m[id]
+	s.mu.RUnlock()
+	if !ok {
+		return notFound
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *MemoryStore) remove(m map[string][]byte, id string, notFound error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := m[id]; !ok {
+		return notFound
+	}
+	delete(m, id)
+	return nil
+}
+
+func (s *MemoryStore) ids(m map[string][]byte) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
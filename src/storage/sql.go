@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"axis/src/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+const (
+	contractsTable        = "axis_contracts"
+	contractVersionsTable = "axis_contract_versions"
+	connectorsTable       = "axis_connectors"
+)
+
+// SQLStore keeps contracts and connectors as JSON documents in a shared
+// Postgres or MySQL database, so several Axis replicas can serve one catalog.
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+func NewSQLStore(driver, dsn string) (*SQLStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SQLStore{db: db, driver: driver}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close releases the underlying database handle
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) SaveContract(contract *models.Contract) error {
+	return s.put(contractsTable, contract.ID, contract)
+}
+
+func (s *SQLStore) LoadContract(id string) (*models.Contract, error) {
+	var contract models.Contract
+	if err := s.get(contractsTable, id, &contract, ErrContractNotFound); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+func (s *SQLStore) ListContracts() ([]models.Contract, error) {
+	var contracts = []models.Contract{}
+	err := s.list(contractsTable, func(data []byte) {
+		var contract models.Contract
+		if json.Unmarshal(data, &contract) == nil {
+			contracts = append(contracts, contract)
+		}
+	})
+	return contracts, err
+}
+
+func (s *SQLStore) DeleteContract(id string) error {
+	if err := s.remove(contractsTable, id, ErrContractNotFound); err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE id = %s", contractVersionsTable, s.placeholder(1))
+	_, err := s.db.Exec(stmt, id)
+	return err
+}
+
+func (s *SQLStore) SaveContractVersion(contract *models.Contract) error {
+	data, err := json.Marshal(contract)
+	if err != nil {
+		return err
+	}
+
+	// A plain INSERT relies on the primary key to keep revisions immutable
+	stmt := fmt.Sprintf("INSERT INTO %s (id, version, data) VALUES (%s, %s, %s)",
+		contractVersionsTable, s.placeholder(1), s.placeholder(2), s.placeholder(3))
+	if _, err := s.db.Exec(stmt, contract.ID, contract.Version, string(data)); err != nil {
+		if _, loadErr := s.LoadContractVersion(contract.ID, contract.Version); loadErr == nil {
+			return ErrVersionExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) LoadContractVersion(id string, version int) (*models.Contract, error) {
+	var data string
+	stmt := fmt.Sprintf("SELECT data FROM %s WHERE id = %s AND version = %s",
+		contractVersionsTable, s.placeholder(1), s.placeholder(2))
+	if err := s.db.QueryRow(stmt, id, version).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+
+	var contract models.Contract
+	if err := json.Unmarshal([]byte(data), &contract); err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+func (s *SQLStore) ListContractVersions(id string) ([]models.Contract, error) {
+	stmt := fmt.Sprintf("SELECT data FROM %s WHERE id = %s ORDER BY version", contractVersionsTable, s.placeholder(1))
+	rows, err := s.db.Query(stmt, id)
+	if err != nil {
+		return []models.Contract{}, err
+	}
+	defer rows.Close()
+
+	var contracts = []models.Contract{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return contracts, err
+		}
+		var contract models.Contract
+		if json.Unmarshal([]byte(data), &contract) == nil {
+			contracts = append(contracts, contract)
+		}
+	}
+	return contracts, rows.Err()
+}
+
+func (s *SQLStore) SaveConnector(connector *models.Connector) error {
+	return s.put(connectorsTable, connector.ID, connector)
+}
+
+func (s *SQLStore) LoadConnector(id string) (*models.Connector, error) {
+	var connector models.Connector
+	if err := s.get(connectorsTable, id, &connector, ErrConnectorNotFound); err != nil {
+		return nil, err
+	}
+	return &connector, nil
+}
+
+func (s *SQLStore) ListConnectors() ([]models.Connector, error) {
+	var connectors = []models.Connector{}
+	err := s.list(connectorsTable, func(data []byte) {
+		var connector models.Connector
+		if json.Unmarshal(data, &connector) == nil {
+			connectors = append(connectors, connector)
+		}
+	})
+	return connectors, err
+}
+
+func (s *SQLStore) DeleteConnector(id string) error {
+	return s.remove(connectorsTable, id, ErrConnectorNotFound)
+}
+
+func (s *SQLStore) migrate() error {
+	for _, table := range []string{contractsTable, connectorsTable} {
+		stmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) PRIMARY KEY, data TEXT NOT NULL)", table)
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	stmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) NOT NULL, version INTEGER NOT NULL, data TEXT NOT NULL, PRIMARY KEY (id, version))", contractVersionsTable)
+	_, err := s.db.Exec(stmt)
+	return err
+}
+
+// placeholder returns the bind parameter syntax for the i-th argument
+func (s *SQLStore) placeholder(i int) string {
+	if s.driver == "postgres" {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+func (s *SQLStore) put(table, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var stmt string
+	switch s.driver {
+	case "postgres":
+		stmt = fmt.Sprintf("INSERT INTO %s (id, data) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data", table)
+	default:
+		stmt = fmt.Sprintf("INSERT INTO %s (id, data) VALUES (?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data)", table)
+	}
+
+	_, err = s.db.Exec(stmt, id, string(data))
+	return err
+}
+
+func (s *SQLStore) get(table, id string, v any, notFound error) error {
+	var data string
+	stmt := fmt.Sprintf("SELECT data FROM %s WHERE id = %s", table, s.placeholder(1))
+	if err := s.db.QueryRow(stmt, id).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return notFound
+		}
+		return err
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+func (s *SQLStore) list(table string, fn func(data []byte)) error {
+	rows, err := s.db.Query(fmt.Sprintf("SELECT data FROM %s ORDER BY id", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		fn([]byte(data))
+	}
+	return rows.Err()
+}
+
+func (s *SQLStore) remove(table, id string, notFound error) error {
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE id = %s", table, s.placeholder(1))
+	res, err := s.db.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"axis/src/models"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrContractNotFound  = errors.New("contract not found")
+	ErrConnectorNotFound = errors.New("connector not found")
+	ErrVersionNotFound   = errors.New("contract version not found")
+	ErrVersionExists     = errors.New("contract version already exists")
+)
+
+// ContractStore persists data contracts. Besides the current definition it
+// keeps every published revision, which must never be overwritten.
+type ContractStore interface {
+	SaveContract(contract *models.Contract) error
+	LoadContract(id string) (*models.Contract, error)
+	ListContracts() ([]models.Contract, error)
+	DeleteContract(id string) error
+
+	SaveContractVersion(contract *models.Contract) error
+	LoadContractVersion(id string, version int) (*models.Contract, error)
+	ListContractVersions(id string) ([]models.Contract, error)
+}
+
+// ConnectorStore persists database connectors
+type ConnectorStore interface {
+	SaveConnector(connector *models.Connector) error
+	LoadConnector(id string) (*models.Connector, error)
+	ListConnectors() ([]models.Connector, error)
+	DeleteConnector(id string) error
+}
+
+// Store is a backend holding both contracts and connectors
+type Store interface {
+	ContractStore
+	ConnectorStore
+}
+
+// Config selects and configures the storage backend
+type Config struct {
+	Backend       string // "file" (default), "memory", "postgres" or "mysql"
+	DSN           string // Connection string for the SQL backends
+	ContractsDir  string // Directory for contract files when using the file backend
+	ConnectorsDir string // Directory for connector files when using the file backend
+}
+
+// Open creates the storage backend described by the configuration
+func Open(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", "file":
+		return NewFileStore(cfg.ContractsDir, cfg.ConnectorsDir), nil
+	case "memory":
+		return NewMemoryStore(), nil
+	case "postgres", "mysql":
+		return NewSQLStore(cfg.Backend, cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"axis/src/models"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exerciseStore runs the same round trip against any Store implementation.
+func exerciseStore(t *testing.T, store Store) {
+	contract := &models.Contract{ID: "contract-1", Name: "Countries"}
+	assert.NoError(t, store.SaveContract(contract))
+
+	loaded, err := store.LoadContract("contract-1")
+	assert.NoError(t, err)
+	assert.Equal(t, contract, loaded)
+
+	contracts, err := store.ListContracts()
+	assert.NoError(t, err)
+	assert.Len(t, contracts, 1)
+
+	assert.NoError(t, store.DeleteContract("contract-1"))
+	_, err = store.LoadContract("contract-1")
+	assert.ErrorIs(t, err, ErrContractNotFound)
+	assert.ErrorIs(t, store.DeleteContract("contract-1"), ErrContractNotFound)
+
+	connector := &models.Connector{ID: "connector-1", Type: "postgres", Config: models.DatabaseConfig{Host: "localhost", Port: 5432}}
+	assert.NoError(t, store.SaveConnector(connector))
+
+	loadedConnector, err := store.LoadConnector("connector-1")
+	assert.NoError(t, err)
+	assert.Equal(t, connector, loadedConnector)
+
+	connectors, err := store.ListConnectors()
+	assert.NoError(t, err)
+	assert.Len(t, connectors, 1)
+
+	assert.NoError(t, store.DeleteConnector("connector-1"))
+	_, err = store.LoadConnector("connector-1")
+	assert.ErrorIs(t, err, ErrConnectorNotFound)
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	exerciseStore(t, NewFileStore(filepath.Join(dir, "contracts"), filepath.Join(dir, "connectors")))
+}
+
+func TestMemoryStore(t *testing.T) {
+	exerciseStore(t, NewMemoryStore())
+}
+
+func TestMemoryStore_IsolatesCallers(t *testing.T) {
+	store := NewMemoryStore()
+	contract := &models.Contract{ID: "contract-1", Name: "Original"}
+	assert.NoError(t, store.SaveContract(contract))
+
+	contract.Name = "Changed after save"
+
+	loaded, err := store.LoadContract("contract-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Original", loaded.Name)
+}
+
+func TestOpen(t *testing.T) {
+	store, err := Open(Config{Backend: "memory"})
+	assert.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	store, err = Open(Config{ContractsDir: t.TempDir(), ConnectorsDir: t.TempDir()})
+	assert.NoError(t, err)
+	assert.IsType(t, &FileStore{}, store)
+
+	_, err = Open(Config{Backend: "etcd"})
+	assert.Error(t, err)
+}
+
+func TestContractVersions(t *testing.T) {
+	dir := t.TempDir()
+	stores := map[string]Store{
+		"file":   NewFileStore(filepath.Join(dir, "contracts"), filepath.Join(dir, "connectors")),
+		"memory": NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, version := range []int{2, 1} {
+				contract := &models.Contract{ID: "contract-1", Version: version}
+				assert.NoError(t, store.SaveContractVersion(contract))
+				assert.NoError(t, store.SaveContract(contract))
+			}
+
+			// Published revisions can never be overwritten
+			assert.ErrorIs(t, store.SaveContractVersion(&models.Contract{ID: "contract-1", Version: 1}), ErrVersionExists)
+
+			versions, err := store.ListContractVersions("contract-1")
+			assert.NoError(t, err)
+			if assert.Len(t, versions, 2) {
+				assert.Equal(t, 1, versions[0].Version)
+				assert.Equal(t, 2, versions[1].Version)
+			}
+
+			_, err = store.LoadContractVersion("contract-1", 3)
+			assert.ErrorIs(t, err, ErrVersionNotFound)
+
+			// Deleting a contract removes its history as well
+			assert.NoError(t, store.DeleteContract("contract-1"))
+			versions, err = store.ListContractVersions("contract-1")
+			assert.NoError(t, err)
+			assert.Empty(t, versions)
+		})
+	}
+}
+
+func TestFileStore_ConnectorFilesAreOwnerOnly(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStore(filepath.Join(dir, "contracts"), filepath.Join(dir, "connectors"))
+
+	// A file written before connectors were protected is tightened on save
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "connectors"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "connectors", "legacy.json"), []byte(`{}`), 0644))
+
+	for _, id := range []string{"legacy", "fresh"} {
+		assert.NoError(t, store.SaveConnector(&models.Connector{ID: id}))
+		info, err := os.Stat(filepath.Join(dir, "connectors", id+".json"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), id)
+	}
+}
+
+func TestFileStore_ListsBeforeFirstWrite(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStore(filepath.Join(dir, "contracts"), filepath.Join(dir, "connectors"))
+
+	contracts, err := store.ListContracts()
+	assert.NoError(t, err)
+	assert.Empty(t, contracts)
+
+	connectors, err := store.ListConnectors()
+	assert.NoError(t, err)
+	assert.Empty(t, connectors)
+}